@@ -23,20 +23,22 @@ import (
 )
 
 const (
-	AMOAppVersion = "v1.8.3"
+	AMOAppVersion = "v1.9.0"
 )
 
 // protocol versions supported by this app,
 var AMOProtocolVersions = map[uint64]AMOProtocol{
 	uint64(0x4): &AMOProtocolV4{},
 	uint64(0x5): &AMOProtocolV5{},
+	uint64(0x6): &AMOProtocolV6{},
 }
 
 // protocol versions and app versions supporting them
 var AMOProtocolCompatMap = map[uint64]string{
 	uint64(0x3): "v1.6.x",
-	uint64(0x4): "v1.7.x, v1.8.x",
-	uint64(0x5): "v1.8.x",
+	uint64(0x4): "v1.7.x, v1.8.x, v1.9.x",
+	uint64(0x5): "v1.8.x, v1.9.x",
+	uint64(0x6): "v1.9.x",
 }
 
 // Output are sorted by voting power.
@@ -334,7 +336,9 @@ func (app *AMOApp) Query(reqQuery abci.RequestQuery) (resQuery abci.ResponseQuer
 		reqs = append(reqs[:0], reqs[1:]...) // remove empty string
 	}
 
-	if len(reqs) == 0 || len(reqs) > 2 {
+	// only sub-resources of a UDC take three path segments
+	if len(reqs) == 0 || len(reqs) > 3 ||
+		(len(reqs) == 3 && reqs[0] != "udc") {
 		resQuery.Code = code.QueryCodeBadPath
 		return resQuery
 	}
@@ -355,13 +359,34 @@ func (app *AMOApp) Query(reqQuery abci.RequestQuery) (resQuery abci.ResponseQuer
 			return resQuery
 		}
 	case "udc":
-		resQuery = queryUDC(app.store, reqQuery.Data)
+		switch {
+		case len(reqs) == 1:
+			resQuery = queryUDC(app.store, reqQuery.Data)
+		case len(reqs) == 3 && reqs[2] == "holders":
+			resQuery = queryUDCHolders(app.store, app.state.Height,
+				reqs[1], reqQuery.Data)
+		case len(reqs) == 3 && reqs[2] == "stats":
+			resQuery = queryUDCStats(app.store, app.state.Height, reqs[1])
+		default:
+			resQuery.Code = code.QueryCodeBadPath
+			return resQuery
+		}
+	case "allowance":
+		switch len(reqs) {
+		case 1:
+			resQuery = queryAllowance(app.store, "", reqQuery.Data)
+		case 2:
+			resQuery = queryAllowance(app.store, reqs[1], reqQuery.Data)
+		default:
+			resQuery.Code = code.QueryCodeBadPath
+			return resQuery
+		}
 	case "udclock":
 		if len(reqs) != 2 {
 			resQuery.Code = code.QueryCodeBadPath
 			return resQuery
 		}
-		resQuery = queryUDCLock(app.store, reqs[1], reqQuery.Data)
+		resQuery = queryUDCLock(app.store, app.state.Height, reqs[1], reqQuery.Data)
 	case "stake":
 		resQuery = queryStake(app.store, reqQuery.Data)
 	case "delegate":
@@ -370,8 +395,18 @@ func (app *AMOApp) Query(reqQuery abci.RequestQuery) (resQuery abci.ResponseQuer
 		resQuery = queryValidator(app.store, reqQuery.Data)
 	case "hibernate":
 		resQuery = queryHibernate(app.store, reqQuery.Data)
+	case "enckey":
+		resQuery = queryEncKey(app.store, reqQuery.Data)
+	case "dealer":
+		resQuery = queryDealer(app.store, reqQuery.Data)
+	case "agency":
+		resQuery = queryAgency(app.store, reqQuery.Data)
 	case "storage":
 		resQuery = queryStorage(app.store, reqQuery.Data)
+	case "challenge":
+		resQuery = queryChallenge(app.store, reqQuery.Data)
+	case "challenge_miss":
+		resQuery = queryMissedChallenge(app.store, reqQuery.Data)
 	case "draft":
 		resQuery = queryDraft(app.store, reqQuery.Data)
 	case "vote":
@@ -384,6 +419,18 @@ func (app *AMOApp) Query(reqQuery abci.RequestQuery) (resQuery abci.ResponseQuer
 		resQuery = queryUsage(app.store, reqQuery.Data)
 	case "did":
 		resQuery = queryDIDEntry(app.store, reqQuery.Data)
+	case "credential_status":
+		resQuery = queryCredentialStatus(app.store, reqQuery.Data)
+	case "htlc":
+		resQuery = queryHTLC(app.store, reqQuery.Data)
+	case "orderbook":
+		resQuery = queryOrderBook(app.store, reqQuery.Data)
+	case "orders":
+		resQuery = queryOrders(app.store, reqQuery.Data)
+	case "schedule":
+		resQuery = querySchedule(app.store, reqQuery.Data)
+	case "channel":
+		resQuery = queryChannel(app.store, reqQuery.Data)
 	default:
 		resQuery.Code = code.QueryCodeBadPath
 		return resQuery
@@ -398,6 +445,7 @@ func (app *AMOApp) Query(reqQuery abci.RequestQuery) (resQuery abci.ResponseQuer
 func (app *AMOApp) BeginBlock(req abci.RequestBeginBlock) (res abci.ResponseBeginBlock) {
 	app.state.Height = req.Header.Height
 	tx.StateBlockHeight = app.state.Height
+	tx.StateBlockHash = req.Hash
 
 	// upgrade protocol version
 	evs := app.upgradeProtocol()
@@ -465,7 +513,7 @@ func (app *AMOApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
 	}
 
 	if req.Type == abci.CheckTxType_New {
-		if !t.Verify() {
+		if !t.Verify(app.store) {
 			return abci.ResponseCheckTx{
 				Code:      code.TxCodeBadSignature,
 				Log:       "Signature verification failed",
@@ -616,6 +664,28 @@ func (app *AMOApp) EndBlock(req abci.RequestEndBlock) (res abci.ResponseEndBlock
 	evs = app.store.LoosenLockedStakes(false)
 	res.Events = append(res.Events, evs...)
 
+	// steps introduced in protocol v6
+	if app.state.ProtocolVersion >= 0x6 {
+		evs = app.store.LoosenStorageBonds(false)
+		res.Events = append(res.Events, evs...)
+
+		evs = app.store.ExpireChallenges(app.state.Height,
+			app.config.ChallengeSlashRatio, false)
+		res.Events = append(res.Events, evs...)
+
+		evs = app.store.ReleaseEscrows(app.state.Height, false)
+		res.Events = append(res.Events, evs...)
+
+		evs = app.store.ExecuteSchedules(app.state.Height, false)
+		res.Events = append(res.Events, evs...)
+
+		evs = app.store.SettleChannels(app.state.Height, false)
+		res.Events = append(res.Events, evs...)
+
+		evs = app.store.MatchOrders(false)
+		res.Events = append(res.Events, evs...)
+	}
+
 	// get lazy validators
 	lazyValidators := []crypto.Address{}
 	if app.state.Height%app.config.LazinessWindow == 0 {
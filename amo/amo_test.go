@@ -84,7 +84,7 @@ func TestQueryVersion(t *testing.T) {
 	req := abci.RequestQuery{Path: "/version"}
 	res := app.Query(req)
 	jsonstr1 := []byte(`{"app_version":"` + AMOAppVersion +
-		`","app_protocol_versions":[4,5],"state_protocol_version":3,` +
+		`","app_protocol_versions":[4,5,6],"state_protocol_version":3,` +
 		`"app_protocol_version":3}`)
 	assert.Equal(t, jsonstr1, res.GetValue())
 
@@ -99,7 +99,7 @@ func TestQueryVersion(t *testing.T) {
 	req = abci.RequestQuery{Path: "/version"}
 	res = app.Query(req)
 	jsonstr2 := []byte(`{"app_version":"` + AMOAppVersion +
-		`","app_protocol_versions":[4,5],"state_protocol_version":4,` +
+		`","app_protocol_versions":[4,5,6],"state_protocol_version":4,` +
 		`"app_protocol_version":4}`)
 	assert.Equal(t, jsonstr2, res.GetValue())
 }
@@ -169,6 +169,77 @@ func TestQueryBalance(t *testing.T) {
 	assert.Equal(t, string(jsonstr), res.Log)
 }
 
+func TestQueryAllowance(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+
+	var req abci.RequestQuery
+	var res abci.ResponseQuery
+
+	req = abci.RequestQuery{Path: "/allowance"}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoKey, res.Code)
+
+	key, _ := json.Marshal(map[string]crypto.Address{
+		"owner": makeAccAddr("owner"),
+	})
+	req = abci.RequestQuery{Path: "/allowance", Data: key}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeBadKey, res.Code)
+
+	app.store.SetUDCAllowance(123, makeAccAddr("owner"),
+		makeAccAddr("spender"), new(types.Currency).Set(500))
+	app.store.Save()
+
+	key, _ = json.Marshal(map[string]crypto.Address{
+		"owner":   makeAccAddr("owner"),
+		"spender": makeAccAddr("spender"),
+	})
+	req = abci.RequestQuery{Path: "/allowance", Data: key}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t, `"0"`, res.Log)
+
+	req = abci.RequestQuery{Path: "/allowance/123", Data: key}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t, `"500"`, res.Log)
+	assert.Equal(t, key, res.Key)
+}
+
+func TestQueryUDCLock(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+
+	holder := makeAccAddr("holder")
+	app.store.SetUDCLockSchedule(123, holder, &types.UDCLock{
+		Amount: *new(types.Currency).Set(1000),
+		Start:  100,
+		Cliff:  150,
+		End:    200,
+	})
+	app.store.Save()
+	app.state.Height = 180
+
+	var req abci.RequestQuery
+	var res abci.ResponseQuery
+
+	req = abci.RequestQuery{Path: "/udclock"}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeBadPath, res.Code)
+
+	key, _ := json.Marshal(holder)
+	req = abci.RequestQuery{Path: "/udclock/123", Data: key}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t,
+		`{"amount":"1000","start":100,"cliff":150,"end":200,"locked":"200"}`,
+		res.Log)
+
+	req = abci.RequestQuery{Path: "/udclock/124", Data: key}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t, `{"amount":"0","locked":"0"}`, res.Log)
+}
+
 func TestQueryStorage(t *testing.T) {
 	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
 
@@ -210,6 +281,81 @@ func TestQueryStorage(t *testing.T) {
 	assert.Equal(t, string(barr), res.Log)
 }
 
+func TestQueryEncKey(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+
+	// populate db store
+	encKey := types.EncKey{
+		Key:    []byte("dummy key"),
+		Height: 1,
+	}
+	app.store.SetEncKey(makeAccAddr("recipient"), &encKey)
+	app.store.Save()
+
+	// query vars
+	var req abci.RequestQuery
+	var res abci.ResponseQuery
+	var barr []byte
+
+	// no key
+	req = abci.RequestQuery{Path: "/enckey"}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoKey, res.Code)
+
+	// nonexistent key
+	barr, _ = json.Marshal(makeAccAddr("other"))
+	req = abci.RequestQuery{Path: "/enckey", Data: []byte(barr)}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoMatch, res.Code)
+
+	// valid match
+	barr, _ = json.Marshal(makeAccAddr("recipient"))
+	req = abci.RequestQuery{Path: "/enckey", Data: []byte(barr)}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	barr, _ = json.Marshal(encKey)
+	assert.Equal(t, barr, res.Value)
+	assert.Equal(t, req.Data, res.Key)
+}
+
+func TestQueryDealer(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+
+	// populate db store
+	dealer := types.Dealer{
+		MaxFeeRate: 0.1,
+		Volume:     *new(types.Currency).SetAMO(10),
+		Fees:       *new(types.Currency).SetAMO(1),
+	}
+	app.store.SetDealer(makeAccAddr("dealer"), &dealer)
+	app.store.Save()
+
+	// query vars
+	var req abci.RequestQuery
+	var res abci.ResponseQuery
+	var barr []byte
+
+	// no key
+	req = abci.RequestQuery{Path: "/dealer"}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoKey, res.Code)
+
+	// nonexistent dealer
+	barr, _ = json.Marshal(makeAccAddr("other"))
+	req = abci.RequestQuery{Path: "/dealer", Data: []byte(barr)}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoMatch, res.Code)
+
+	// valid match
+	barr, _ = json.Marshal(makeAccAddr("dealer"))
+	req = abci.RequestQuery{Path: "/dealer", Data: []byte(barr)}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	barr, _ = json.Marshal(dealer)
+	assert.Equal(t, barr, res.Value)
+	assert.Equal(t, req.Data, res.Key)
+}
+
 func TestQueryParcel(t *testing.T) {
 	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
 
@@ -367,6 +513,28 @@ func TestQueryRequest(t *testing.T) {
 	req = abci.RequestQuery{Path: "/request", Data: key}
 	res = app.Query(req)
 	assert.Equal(t, code.QueryCodeBadKey, res.Code)
+
+	// list
+	delete(keyMap, "target")
+	keyMap["recipient"] = addr
+	key, _ = json.Marshal(keyMap)
+	req = abci.RequestQuery{Path: "/request", Data: key}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	requestEx.Target = parcelID
+	jsonstr, _ = json.Marshal(types.RequestList{
+		Requests: []*types.RequestEx{&requestEx},
+	})
+	assert.Equal(t, []byte(jsonstr), res.Value)
+
+	key, _ = json.Marshal(map[string]interface{}{
+		"recipient": tmbytes.HexBytes(wrongAddr),
+		"num":       10,
+	})
+	req = abci.RequestQuery{Path: "/request", Data: key}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t, `{"requests":[]}`, res.Log)
 }
 
 func TestQueryUsage(t *testing.T) {
@@ -1490,9 +1658,80 @@ func TestQueryDID(t *testing.T) {
 	res = app.Query(req)
 	assert.Equal(t, code.QueryCodeOK, res.Code)
 	assert.Equal(t, []byte(`"myid"`), res.Key)
-	jsonstr, _ = json.Marshal(entry)
+	jsonstr, _ = json.Marshal(entry.ResolutionResult())
 	assert.Equal(t, jsonstr, res.Value)
+	var result types.DIDResolutionResult
+	json.Unmarshal(res.Value, &result)
+	assert.Equal(t, types.DIDContentTypeJSON,
+		result.ResolutionMetadata.ContentType)
+	assert.Equal(t, jsonDoc, []byte(result.Document))
+	assert.Equal(t, makeAccAddr("me"), result.DocumentMetadata.Owner)
+
+	// deactivated
+	entry.Document = nil
+	entry.Deactivated = true
+	entry.Created = 3
+	entry.Updated = 5
+	app.store.SetDIDEntry("myid", entry)
+	app.store.Save()
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	result = types.DIDResolutionResult{}
+	json.Unmarshal(res.Value, &result)
+	assert.Equal(t, types.DIDDocumentMetadata{
+		Owner:       makeAccAddr("me"),
+		Created:     3,
+		Updated:     5,
+		Deactivated: true,
+	}, result.DocumentMetadata)
+	assert.Equal(t, "", result.ResolutionMetadata.ContentType)
+
+	// versions
+	entry = &types.DIDEntry{
+		Owner:    makeAccAddr("me"),
+		Document: []byte(`{"v":1}`),
+		Created:  10,
+		Version:  1,
+	}
+	app.store.SetDIDEntry("did:amo:myid", entry)
+	entry.Document = []byte(`{"v":2}`)
+	entry.Updated = 20
+	entry.Version = 2
+	app.store.SetDIDEntry("did:amo:myid", entry)
+	app.store.Save()
+
+	req = abci.RequestQuery{Path: "/did",
+		Data: []byte(`"did:amo:myid?versionId=1"`)}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	result = types.DIDResolutionResult{}
+	json.Unmarshal(res.Value, &result)
+	assert.Equal(t, []byte(`{"v":1}`), []byte(result.Document))
+	assert.Equal(t, "1", result.DocumentMetadata.VersionID)
+	assert.Equal(t, "2", result.DocumentMetadata.NextVersionID)
+	assert.Equal(t, int64(20), result.DocumentMetadata.NextUpdate)
+
+	req = abci.RequestQuery{Path: "/did",
+		Data: []byte(`"did:amo:myid?versionTime=25"`)}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	result = types.DIDResolutionResult{}
+	json.Unmarshal(res.Value, &result)
+	assert.Equal(t, []byte(`{"v":2}`), []byte(result.Document))
+	assert.Equal(t, "2", result.DocumentMetadata.VersionID)
+	assert.Equal(t, "", result.DocumentMetadata.NextVersionID)
+
+	req = abci.RequestQuery{Path: "/did",
+		Data: []byte(`"did:amo:myid?versionTime=5"`)}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoMatch, res.Code)
+
+	req = abci.RequestQuery{Path: "/did",
+		Data: []byte(`"did:amo:myid?versionId=x"`)}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeBadKey, res.Code)
 
+	req = abci.RequestQuery{Path: "/did", Data: []byte(`"myid"`)}
 	app.store.DeleteDIDEntry("myid")
 	app.store.Save()
 
@@ -1500,6 +1739,51 @@ func TestQueryDID(t *testing.T) {
 	assert.Equal(t, code.QueryCodeNoMatch, res.Code)
 }
 
+func TestQueryCredentialStatus(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+
+	var req abci.RequestQuery
+	var res abci.ResponseQuery
+
+	req = abci.RequestQuery{Path: "/credential_status"}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoKey, res.Code)
+
+	req = abci.RequestQuery{Path: "/credential_status",
+		Data: []byte(`{"issuer":"did:amo:issuer"}`)}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeBadKey, res.Code)
+
+	req = abci.RequestQuery{Path: "/credential_status",
+		Data: []byte(`{"issuer":"did:amo:issuer","credential":"cred1"}`)}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoMatch, res.Code)
+
+	app.store.SetDIDEntry("did:amo:issuer", &types.DIDEntry{
+		Owner:    makeAccAddr("me"),
+		Document: []byte(`{"id":"did:amo:issuer"}`),
+	})
+	app.store.Save()
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t,
+		`{"status":"active","issuer":"did:amo:issuer","credential":"cred1"}`,
+		res.Log)
+
+	app.store.SetCredentialStatus("did:amo:issuer", "cred1",
+		&types.CredentialStatus{
+			Status: types.CredentialRevoked,
+			Height: 3,
+		})
+	app.store.Save()
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t, req.Data, res.Key)
+	assert.Equal(t,
+		`{"status":"revoked","height":3,"issuer":"did:amo:issuer","credential":"cred1"}`,
+		res.Log)
+}
+
 func TestQueryHibernate(t *testing.T) {
 	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
 
@@ -1532,3 +1816,240 @@ func TestQueryHibernate(t *testing.T) {
 	res = app.Query(req)
 	assert.Equal(t, code.QueryCodeNoMatch, res.Code)
 }
+
+func TestQueryUDCHolders(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+
+	app.store.SetUDC(123, &types.UDC{Total: *new(types.Currency).Set(1000)})
+	app.store.SetUDCBalance(123, makeAccAddr("alice"),
+		new(types.Currency).Set(600))
+	app.store.SetUDCBalance(123, makeAccAddr("bob"),
+		new(types.Currency).Set(400))
+	app.store.SetUDCLockSchedule(123, makeAccAddr("bob"), &types.UDCLock{
+		Amount: *new(types.Currency).Set(400),
+		Start:  100,
+		End:    200,
+	})
+	app.store.Save()
+	app.state.Height = 150
+
+	var req abci.RequestQuery
+	var res abci.ResponseQuery
+
+	req = abci.RequestQuery{Path: "/udc/123/owner"}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeBadPath, res.Code)
+	req = abci.RequestQuery{Path: "/balance/123/holders"}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeBadPath, res.Code)
+	req = abci.RequestQuery{Path: "/udc/124/holders"}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoMatch, res.Code)
+
+	// first page
+	req = abci.RequestQuery{Path: "/udc/123/holders", Data: []byte(`{"num":1}`)}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	var list types.UDCHolderList
+	assert.NoError(t, json.Unmarshal(res.Value, &list))
+	assert.Equal(t, 1, len(list.Holders))
+	assert.NotNil(t, list.Next)
+
+	// next page
+	key, _ := json.Marshal(map[string]interface{}{"from": list.Next, "num": 1})
+	req = abci.RequestQuery{Path: "/udc/123/holders", Data: key}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	list = types.UDCHolderList{}
+	assert.NoError(t, json.Unmarshal(res.Value, &list))
+	assert.Equal(t, 1, len(list.Holders))
+	assert.Nil(t, list.Next)
+	assert.Equal(t, *new(types.Currency).Set(200), list.Holders[0].Lock.Locked)
+
+	// stats
+	req = abci.RequestQuery{Path: "/udc/124/stats"}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoMatch, res.Code)
+	req = abci.RequestQuery{Path: "/udc/123/stats"}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t,
+		`{"holders":2,"total":"1000","locked":"200","circulating":"800"}`,
+		res.Log)
+}
+
+func TestQueryHTLC(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+
+	id := types.HTLCID(makeAccAddr("sender"), makeAccAddr("recipient"),
+		[]byte("hashlock"))
+	app.store.SetHTLC(id, &types.HTLC{
+		Sender:    makeAccAddr("sender"),
+		Recipient: makeAccAddr("recipient"),
+		Amount:    *new(types.Currency).Set(100),
+		HashLock:  []byte("hashlock"),
+		Timeout:   200,
+	})
+	app.store.Save()
+
+	var req abci.RequestQuery
+	var res abci.ResponseQuery
+
+	req = abci.RequestQuery{Path: "/htlc"}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoKey, res.Code)
+
+	key, _ := json.Marshal(tmbytes.HexBytes([]byte("unknown")))
+	req = abci.RequestQuery{Path: "/htlc", Data: key}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoMatch, res.Code)
+
+	key, _ = json.Marshal(id)
+	req = abci.RequestQuery{Path: "/htlc", Data: key}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	var htlc types.HTLC
+	assert.NoError(t, json.Unmarshal(res.Value, &htlc))
+	assert.Equal(t, int64(200), htlc.Timeout)
+	assert.Equal(t, key, res.Key)
+}
+
+func TestQueryOrderBook(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.state.ProtocolVersion = 0x5
+
+	place := func(owner string, side string, price, amount uint64) {
+		order := &types.Order{
+			Owner:  makeAccAddr(owner),
+			Base:   123,
+			Side:   side,
+			Price:  *new(types.Currency).Set(price),
+			Amount: *new(types.Currency).Set(amount),
+			Escrow: *new(types.Currency).Set(price * amount),
+		}
+		app.store.SetOrder(app.store.NextOrderID(), order)
+		app.store.MarkOrderMarket(123, 0)
+	}
+	place("alice", types.OrderSell, types.OneAMOUint64, 100)
+	place("alice", types.OrderSell, types.OneAMOUint64, 50)
+	place("alice", types.OrderSell, 3*types.OneAMOUint64, 50)
+	place("bob", types.OrderBuy, types.OneAMOUint64, 120)
+
+	countFills := func(res abci.ResponseEndBlock) int {
+		fills := 0
+		for _, ev := range res.Events {
+			if ev.Type == "order_fill" {
+				fills++
+			}
+		}
+		return fills
+	}
+
+	// orders are not matched before protocol v6
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	res := app.EndBlock(abci.RequestEndBlock{})
+	assert.Equal(t, 0, countFills(res))
+
+	app.state.ProtocolVersion = 0x6
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	res = app.EndBlock(abci.RequestEndBlock{})
+	assert.Equal(t, 2, countFills(res))
+	app.store.Save()
+
+	var req abci.RequestQuery
+	var resQuery abci.ResponseQuery
+
+	req = abci.RequestQuery{Path: "/orderbook"}
+	resQuery = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoKey, resQuery.Code)
+
+	req = abci.RequestQuery{Path: "/orderbook",
+		Data: []byte(`{"base":123,"quote":0}`)}
+	resQuery = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, resQuery.Code)
+	assert.Equal(t,
+		`{"base":123,"quote":0,"bids":[],"asks":[`+
+			`{"price":"1000000000000000000","amount":"30"},`+
+			`{"price":"3000000000000000000","amount":"50"}]}`,
+		resQuery.Log)
+
+	req = abci.RequestQuery{Path: "/orders", Data: []byte(`{}`)}
+	resQuery = app.Query(req)
+	assert.Equal(t, code.QueryCodeBadKey, resQuery.Code)
+
+	key, _ := json.Marshal(map[string]interface{}{
+		"owner": makeAccAddr("alice"),
+		"num":   1,
+	})
+	req = abci.RequestQuery{Path: "/orders", Data: key}
+	resQuery = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, resQuery.Code)
+	var list types.OrderList
+	assert.NoError(t, json.Unmarshal(resQuery.Value, &list))
+	assert.Equal(t, 1, len(list.Orders))
+	assert.Equal(t, uint64(2), list.Orders[0].ID)
+	assert.Equal(t, uint64(3), list.Next)
+}
+
+func TestQuerySchedule(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+
+	app.store.SetSchedule(1, &types.Schedule{
+		Sender:    makeAccAddr("sender"),
+		Recipient: makeAccAddr("recipient"),
+		Amount:    *new(types.Currency).Set(100),
+		Count:     1,
+		Next:      10,
+	})
+	app.store.Save()
+
+	var req abci.RequestQuery
+	var res abci.ResponseQuery
+
+	req = abci.RequestQuery{Path: "/schedule"}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoKey, res.Code)
+
+	req = abci.RequestQuery{Path: "/schedule", Data: []byte(`2`)}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoMatch, res.Code)
+
+	req = abci.RequestQuery{Path: "/schedule", Data: []byte(`1`)}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	var schedule types.ScheduleEx
+	assert.NoError(t, json.Unmarshal(res.Value, &schedule))
+	assert.Equal(t, uint64(1), schedule.ID)
+	assert.Equal(t, int64(10), schedule.Next)
+}
+
+func TestQueryChannel(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+
+	app.store.SetChannel(1, &types.Channel{
+		Sender:    makeAccAddr("sender"),
+		Recipient: makeAccAddr("recipient"),
+		Deposit:   *new(types.Currency).Set(500),
+		Period:    10,
+	})
+	app.store.Save()
+
+	var req abci.RequestQuery
+	var res abci.ResponseQuery
+
+	req = abci.RequestQuery{Path: "/channel"}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoKey, res.Code)
+
+	req = abci.RequestQuery{Path: "/channel", Data: []byte(`2`)}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoMatch, res.Code)
+
+	req = abci.RequestQuery{Path: "/channel", Data: []byte(`1`)}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	var channel types.ChannelEx
+	assert.NoError(t, json.Unmarshal(res.Value, &channel))
+	assert.Equal(t, uint64(1), channel.ID)
+	assert.Equal(t, *new(types.Currency).Set(500), channel.Deposit)
+}
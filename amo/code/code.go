@@ -39,6 +39,28 @@ const (
 	TxCodeNoStorage
 	TxCodeUDCNotFound
 	TxCodeNotFound
+	TxCodeAlreadyChallenged
+	TxCodeChallengeNotFound
+	TxCodeBadProof
+	TxCodeTermsMismatch
+	TxCodeEscrowNotFound
+	TxCodeAlreadyDisputed
+	TxCodeDealerNotFound
+	TxCodeDIDNotFound
+	TxCodeDIDDeactivated
+	TxCodeBadDIDDocument
+	TxCodeCredentialRevoked
+	TxCodeNotEnoughAllowance
+	TxCodeMaxSupplyExceeded
+	TxCodeFrozen
+	TxCodeHTLCNotFound
+	TxCodeHTLCClosed
+	TxCodeHTLCExpired
+	TxCodeHTLCNotExpired
+	TxCodeBadPreimage
+	TxCodeOrderNotFound
+	TxCodeScheduleNotFound
+	TxCodeChannelNotFound
 	TxCodeUnknown uint32 = 1000
 )
 
@@ -85,6 +107,28 @@ var errMap map[uint32]error = map[uint32]error{
 	TxCodeNoStorage:             errors.New("NoStorage"),
 	TxCodeUDCNotFound:           errors.New("UDCNotFound"),
 	TxCodeNotFound:              errors.New("NotFound"),
+	TxCodeAlreadyChallenged:     errors.New("AlreadyChallenged"),
+	TxCodeChallengeNotFound:     errors.New("ChallengeNotFound"),
+	TxCodeBadProof:              errors.New("BadProof"),
+	TxCodeTermsMismatch:         errors.New("TermsMismatch"),
+	TxCodeEscrowNotFound:        errors.New("EscrowNotFound"),
+	TxCodeAlreadyDisputed:       errors.New("AlreadyDisputed"),
+	TxCodeDealerNotFound:        errors.New("DealerNotFound"),
+	TxCodeDIDNotFound:           errors.New("DIDNotFound"),
+	TxCodeDIDDeactivated:        errors.New("DIDDeactivated"),
+	TxCodeBadDIDDocument:        errors.New("BadDIDDocument"),
+	TxCodeCredentialRevoked:     errors.New("CredentialRevoked"),
+	TxCodeNotEnoughAllowance:    errors.New("NotEnoughAllowance"),
+	TxCodeMaxSupplyExceeded:     errors.New("MaxSupplyExceeded"),
+	TxCodeFrozen:                errors.New("Frozen"),
+	TxCodeHTLCNotFound:          errors.New("HTLCNotFound"),
+	TxCodeHTLCClosed:            errors.New("HTLCClosed"),
+	TxCodeHTLCExpired:           errors.New("HTLCExpired"),
+	TxCodeHTLCNotExpired:        errors.New("HTLCNotExpired"),
+	TxCodeBadPreimage:           errors.New("BadPreimage"),
+	TxCodeOrderNotFound:         errors.New("OrderNotFound"),
+	TxCodeScheduleNotFound:      errors.New("ScheduleNotFound"),
+	TxCodeChannelNotFound:       errors.New("ChannelNotFound"),
 	TxCodeUnknown:               errors.New("Unknown"),
 
 	QueryCodeBadPath: errors.New("BadPath"),
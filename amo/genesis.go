@@ -127,6 +127,26 @@ func ParseGenesisStateBytes(data []byte) (*GenAmoAppState, error) {
 	if genState.Config.DraftRefundRate == 0 {
 		genState.Config.DraftRefundRate = types.DefaultDraftRefundRate
 	}
+	if genState.Config.StorageBond.Equals(types.Zero) {
+		sb, err := new(types.Currency).SetString(types.DefaultStorageBond, 10)
+		if err != nil {
+			return nil, err
+		}
+		genState.Config.StorageBond = *sb
+	}
+	if genState.Config.ChallengePeriod == 0 {
+		genState.Config.ChallengePeriod = types.DefaultChallengePeriod
+	}
+	if genState.Config.ChallengeSlashRatio == 0 {
+		genState.Config.ChallengeSlashRatio = types.DefaultChallengeSlashRatio
+	}
+	if genState.Config.ChallengeDeposit.Equals(types.Zero) {
+		cd, err := new(types.Currency).SetString(types.DefaultChallengeDeposit, 10)
+		if err != nil {
+			return nil, err
+		}
+		genState.Config.ChallengeDeposit = *cd
+	}
 	if genState.Config.UpgradeProtocolHeight == 0 {
 		genState.Config.UpgradeProtocolHeight = types.DefaultUpgradeProtocolHeight
 	}
@@ -2,8 +2,10 @@ package amo
 
 import (
 	"encoding/json"
+	"net/url"
 	"sort"
 	"strconv"
+	"strings"
 
 	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/crypto"
@@ -126,7 +128,7 @@ func queryUDC(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 	return
 }
 
-func queryUDCLock(s *store.Store, udc string, queryData []byte) (res abci.ResponseQuery) {
+func queryUDCLock(s *store.Store, height int64, udc string, queryData []byte) (res abci.ResponseQuery) {
 	if len(queryData) == 0 {
 		res.Log = "error: no query_data"
 		res.Code = code.QueryCodeNoKey
@@ -150,9 +152,129 @@ func queryUDCLock(s *store.Store, udc string, queryData []byte) (res abci.Respon
 	}
 	udcID = uint32(tmp)
 
-	udcLock := s.GetUDCLock(udcID, addr, true)
+	udcLock := s.GetUDCLockSchedule(udcID, addr, true)
 
-	jsonstr, _ := json.Marshal(udcLock)
+	jsonstr, _ := json.Marshal(types.UDCLockEx{
+		UDCLock: udcLock,
+		Locked:  *udcLock.Locked(height),
+	})
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = queryData
+
+	return
+}
+
+func queryUDCHolders(s *store.Store, height int64, udc string, queryData []byte) (res abci.ResponseQuery) {
+	tmp, err := strconv.ParseInt(udc, 10, 32)
+	if err != nil {
+		res.Log = "error: cannot convert udc id"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+	udcID := uint32(tmp)
+
+	// query_data is optional for the first page
+	var key struct {
+		From crypto.Address `json:"from"`
+		Num  int            `json:"num"`
+	}
+	if len(queryData) > 0 {
+		err = json.Unmarshal(queryData, &key)
+		if err != nil {
+			res.Log = "error: unmarshal"
+			res.Code = code.QueryCodeBadKey
+			return
+		}
+	}
+	if key.Num <= 0 || key.Num > maxQueryPageSize {
+		key.Num = maxQueryPageSize
+	}
+
+	if s.GetUDC(udcID, true) == nil {
+		res.Log = "error: no such udc"
+		res.Code = code.QueryCodeNoMatch
+		return
+	}
+
+	holders, next := s.GetUDCHolders(udcID, key.From, key.Num, height, true)
+
+	jsonstr, _ := json.Marshal(types.UDCHolderList{
+		Holders: holders,
+		Next:    next,
+	})
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = queryData
+
+	return
+}
+
+func queryUDCStats(s *store.Store, height int64, udc string) (res abci.ResponseQuery) {
+	tmp, err := strconv.ParseInt(udc, 10, 32)
+	if err != nil {
+		res.Log = "error: cannot convert udc id"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+	udcID := uint32(tmp)
+
+	stats := s.GetUDCStats(udcID, height, true)
+	if stats == nil {
+		res.Log = "error: no such udc"
+		res.Code = code.QueryCodeNoMatch
+		return
+	}
+
+	jsonstr, _ := json.Marshal(stats)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = []byte(udc)
+
+	return
+}
+
+func queryAllowance(s *store.Store, udc string, queryData []byte) (res abci.ResponseQuery) {
+	if len(queryData) == 0 {
+		res.Log = "error: no query_data"
+		res.Code = code.QueryCodeNoKey
+		return
+	}
+
+	var key struct {
+		Owner   crypto.Address `json:"owner"`
+		Spender crypto.Address `json:"spender"`
+	}
+	err := json.Unmarshal(queryData, &key)
+	if err != nil {
+		res.Log = "error: unmarshal"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+	if len(key.Owner) != crypto.AddressSize ||
+		len(key.Spender) != crypto.AddressSize {
+		res.Log = "error: not avaiable address"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+
+	udcID := uint32(0)
+	if udc != "" {
+		tmp, err := strconv.ParseInt(udc, 10, 32)
+		if err != nil {
+			res.Log = "error: cannot convert udc id"
+			res.Code = code.QueryCodeBadKey
+			return
+		}
+		udcID = uint32(tmp)
+	}
+
+	allowance := s.GetUDCAllowance(udcID, key.Owner, key.Spender, true)
+
+	jsonstr, _ := json.Marshal(allowance)
 	res.Log = string(jsonstr)
 	res.Value = jsonstr
 	res.Code = code.QueryCodeOK
@@ -279,6 +401,109 @@ func queryHibernate(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 	return
 }
 
+func queryEncKey(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
+	if len(queryData) == 0 {
+		res.Log = "error: no query_data"
+		res.Code = code.QueryCodeNoKey
+		return
+	}
+
+	var addr crypto.Address
+	err := json.Unmarshal(queryData, &addr)
+	if err != nil {
+		res.Log = "error: unmarshal"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+
+	encKey := s.GetEncKey(addr, true)
+	if encKey == nil {
+		res.Log = "error: no encryption key"
+		res.Code = code.QueryCodeNoMatch
+		return
+	}
+
+	jsonstr, _ := json.Marshal(encKey)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = queryData
+
+	return
+}
+
+func queryDealer(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
+	if len(queryData) == 0 {
+		res.Log = "error: no query_data"
+		res.Code = code.QueryCodeNoKey
+		return
+	}
+
+	var addr crypto.Address
+	err := json.Unmarshal(queryData, &addr)
+	if err != nil {
+		res.Log = "error: unmarshal"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+
+	dealer := s.GetDealer(addr, true)
+	if dealer == nil {
+		res.Log = "error: no such dealer"
+		res.Code = code.QueryCodeNoMatch
+		return
+	}
+
+	jsonstr, _ := json.Marshal(dealer)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = queryData
+
+	return
+}
+
+func queryAgency(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
+	if len(queryData) == 0 {
+		res.Log = "error: no query_data"
+		res.Code = code.QueryCodeNoKey
+		return
+	}
+
+	var addr crypto.Address
+	err := json.Unmarshal(queryData, &addr)
+	if err != nil {
+		res.Log = "error: unmarshal"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+	if len(addr) != crypto.AddressSize {
+		res.Log = "error: not avaiable address"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+
+	requests, usages := s.GetAgencyEntries(addr, true)
+	if len(requests) == 0 && len(usages) == 0 {
+		res.Log = "error: no request or usage"
+		res.Code = code.QueryCodeNoMatch
+		return
+	}
+
+	agencyEx := types.AgencyEx{
+		Requests: requests,
+		Usages:   usages,
+	}
+
+	jsonstr, _ := json.Marshal(agencyEx)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = queryData
+
+	return
+}
+
 func queryStorage(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 	if len(queryData) == 0 {
 		res.Log = "error: no query_data"
@@ -310,6 +535,68 @@ func queryStorage(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 	return
 }
 
+func queryChallenge(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
+	if len(queryData) == 0 {
+		res.Log = "error: no query_data"
+		res.Code = code.QueryCodeNoKey
+		return
+	}
+
+	var id bytes.HexBytes
+	err := json.Unmarshal(queryData, &id)
+	if err != nil {
+		res.Log = "error: unmarshal"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+
+	challenge := s.GetChallenge(id, true)
+	if challenge == nil {
+		res.Log = "error: no such challenge"
+		res.Code = code.QueryCodeNoMatch
+		return
+	}
+
+	jsonstr, _ := json.Marshal(challenge)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = queryData
+
+	return
+}
+
+func queryMissedChallenge(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
+	if len(queryData) == 0 {
+		res.Log = "error: no query_data"
+		res.Code = code.QueryCodeNoKey
+		return
+	}
+
+	var storageID uint32
+	err := json.Unmarshal(queryData, &storageID)
+	if err != nil {
+		res.Log = "error: unmarshal"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+
+	challenges := s.GetMissedChallenges(storageID, true)
+	if len(challenges) == 0 {
+		res.Log = "error: no missed challenge"
+		res.Code = code.QueryCodeNoMatch
+		return
+	}
+
+	jsonstr, _ := json.Marshal(challenges)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = queryData
+
+	return
+}
+
 func queryDraft(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 	if len(queryData) == 0 {
 		res.Log = "error: no query_data"
@@ -425,6 +712,9 @@ func queryParcel(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 		Requests: s.GetRequests(id, true),
 		Usages:   s.GetUsages(id, true),
 	}
+	if ops := s.GetOperators(id, true); len(ops) > 0 {
+		parcelEx.Operators = ops
+	}
 
 	jsonstr, _ := json.Marshal(parcelEx)
 	res.Log = string(jsonstr)
@@ -435,6 +725,24 @@ func queryParcel(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 	return
 }
 
+const maxQueryPageSize = 100
+
+// recipientQueryKey is a query key for requests and usages. When target is
+// missing, it lists the entries of the recipient page by page.
+type recipientQueryKey struct {
+	Recipient bytes.HexBytes `json:"recipient"`
+	Target    bytes.HexBytes `json:"target"`
+	From      bytes.HexBytes `json:"from"`
+	Num       int            `json:"num"`
+}
+
+func (k recipientQueryKey) pageSize() int {
+	if k.Num <= 0 || k.Num > maxQueryPageSize {
+		return maxQueryPageSize
+	}
+	return k.Num
+}
+
 func queryRequest(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 	if len(queryData) == 0 {
 		res.Log = "error: no query_data"
@@ -442,32 +750,42 @@ func queryRequest(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 		return
 	}
 
-	keyMap := make(map[string]bytes.HexBytes)
-	err := json.Unmarshal(queryData, &keyMap)
+	var key recipientQueryKey
+	err := json.Unmarshal(queryData, &key)
 	if err != nil {
 		res.Log = "error: unmarshal"
 		res.Code = code.QueryCodeBadKey
 		return
 	}
-	if _, ok := keyMap["recipient"]; !ok {
+	if len(key.Recipient) == 0 {
 		res.Log = "error: recipient is missing"
 		res.Code = code.QueryCodeBadKey
 		return
 	}
-	if _, ok := keyMap["target"]; !ok {
-		res.Log = "error: target is missing"
-		res.Code = code.QueryCodeBadKey
-		return
-	}
-	addr := crypto.Address(keyMap["recipient"])
+	addr := crypto.Address(key.Recipient)
 	if len(addr) != crypto.AddressSize {
 		res.Log = "error: not avaiable address"
 		res.Code = code.QueryCodeBadKey
 		return
 	}
 
+	// list all requests of the recipient when target is missing
+	if len(key.Target) == 0 {
+		requests, next := s.GetRequestsByRecipient(addr, key.From,
+			key.pageSize(), true)
+		jsonstr, _ := json.Marshal(types.RequestList{
+			Requests: requests,
+			Next:     next,
+		})
+		res.Log = string(jsonstr)
+		res.Value = jsonstr
+		res.Code = code.QueryCodeOK
+		res.Key = queryData
+		return
+	}
+
 	// TODO: parse parcel id
-	parcelID := keyMap["target"]
+	parcelID := key.Target
 
 	request := s.GetRequest(addr, parcelID, true)
 	if request == nil {
@@ -497,32 +815,42 @@ func queryUsage(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 		return
 	}
 
-	keyMap := make(map[string]bytes.HexBytes)
-	err := json.Unmarshal(queryData, &keyMap)
+	var key recipientQueryKey
+	err := json.Unmarshal(queryData, &key)
 	if err != nil {
 		res.Log = "error: unmarshal"
 		res.Code = code.QueryCodeBadKey
 		return
 	}
-	if _, ok := keyMap["recipient"]; !ok {
+	if len(key.Recipient) == 0 {
 		res.Log = "error: recipient is missing"
 		res.Code = code.QueryCodeBadKey
 		return
 	}
-	if _, ok := keyMap["target"]; !ok {
-		res.Log = "error: target is missing"
-		res.Code = code.QueryCodeBadKey
-		return
-	}
-	addr := crypto.Address(keyMap["recipient"])
+	addr := crypto.Address(key.Recipient)
 	if len(addr) != crypto.AddressSize {
 		res.Log = "error: not avaiable address"
 		res.Code = code.QueryCodeBadKey
 		return
 	}
 
+	// list all usages of the recipient when target is missing
+	if len(key.Target) == 0 {
+		usages, next := s.GetUsagesByRecipient(addr, key.From,
+			key.pageSize(), true)
+		jsonstr, _ := json.Marshal(types.UsageList{
+			Usages: usages,
+			Next:   next,
+		})
+		res.Log = string(jsonstr)
+		res.Value = jsonstr
+		res.Code = code.QueryCodeOK
+		res.Key = queryData
+		return
+	}
+
 	// TODO: parse parcel id
-	parcelID := keyMap["target"]
+	parcelID := key.Target
 
 	usage := s.GetUsage(addr, parcelID, true)
 	if usage == nil {
@@ -534,6 +862,7 @@ func queryUsage(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 	usageEx := types.UsageEx{
 		Usage:     usage,
 		Recipient: addr,
+		Escrow:    s.GetEscrow(addr, parcelID, true),
 	}
 
 	jsonstr, _ := json.Marshal(usageEx)
@@ -560,14 +889,269 @@ func queryDIDEntry(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 		return
 	}
 
-	entry := s.GetDIDEntry(id, true)
+	// DID URL may carry versionId or versionTime as query parameters
+	var params url.Values
+	if i := strings.IndexByte(id, '?'); i >= 0 {
+		params, err = url.ParseQuery(id[i+1:])
+		if err != nil {
+			res.Log = "error: malformed query parameters"
+			res.Code = code.QueryCodeBadKey
+			return
+		}
+		id = id[:i]
+	}
+
+	var entry *types.DIDEntry
+	switch {
+	case params.Get("versionId") != "":
+		version, err := strconv.ParseUint(params.Get("versionId"), 10, 64)
+		if err != nil {
+			res.Log = "error: cannot convert versionId"
+			res.Code = code.QueryCodeBadKey
+			return
+		}
+		entry = s.GetDIDEntryVersion(id, version, true)
+	case params.Get("versionTime") != "":
+		height, err := strconv.ParseInt(params.Get("versionTime"), 10, 64)
+		if err != nil {
+			res.Log = "error: cannot convert versionTime"
+			res.Code = code.QueryCodeBadKey
+			return
+		}
+		entry = s.GetDIDEntryAt(id, height, true)
+	default:
+		entry = s.GetDIDEntry(id, true)
+	}
 	if entry == nil {
 		res.Log = "error: no such did entry"
 		res.Code = code.QueryCodeNoMatch
 		return
 	}
 
-	jsonstr, _ := json.Marshal(entry)
+	result := entry.ResolutionResult()
+	next := s.GetDIDEntryVersion(id, entry.Version+1, true)
+	if next != nil {
+		result.DocumentMetadata.NextUpdate = next.VersionTime()
+		result.DocumentMetadata.NextVersionID =
+			strconv.FormatUint(next.Version, 10)
+	}
+
+	jsonstr, _ := json.Marshal(result)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = queryData
+
+	return
+}
+
+func queryCredentialStatus(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
+	if len(queryData) == 0 {
+		res.Log = "error: no query_data"
+		res.Code = code.QueryCodeNoKey
+		return
+	}
+
+	var key struct {
+		Issuer     string `json:"issuer"`
+		Credential string `json:"credential"`
+	}
+	err := json.Unmarshal(queryData, &key)
+	if err != nil {
+		res.Log = "error: unmarshal"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+	if len(key.Issuer) == 0 || len(key.Credential) == 0 {
+		res.Log = "error: issuer or credential is missing"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+
+	if s.GetDIDEntry(key.Issuer, true) == nil {
+		res.Log = "error: no such issuer"
+		res.Code = code.QueryCodeNoMatch
+		return
+	}
+
+	// credential without any entry in the list is considered active
+	status := s.GetCredentialStatus(key.Issuer, key.Credential, true)
+	if status == nil {
+		status = &types.CredentialStatus{Status: types.CredentialActive}
+	}
+
+	jsonstr, _ := json.Marshal(types.CredentialStatusEx{
+		CredentialStatus: status,
+		Issuer:           key.Issuer,
+		Credential:       key.Credential,
+	})
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = queryData
+
+	return
+}
+
+func queryHTLC(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
+	if len(queryData) == 0 {
+		res.Log = "error: no query_data"
+		res.Code = code.QueryCodeNoKey
+		return
+	}
+
+	var id bytes.HexBytes
+	err := json.Unmarshal(queryData, &id)
+	if err != nil {
+		res.Log = "error: unmarshal"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+
+	htlc := s.GetHTLC(id, true)
+	if htlc == nil {
+		res.Log = "error: no such htlc"
+		res.Code = code.QueryCodeNoMatch
+		return
+	}
+
+	jsonstr, _ := json.Marshal(htlc)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = queryData
+
+	return
+}
+
+func queryOrderBook(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
+	if len(queryData) == 0 {
+		res.Log = "error: no query_data"
+		res.Code = code.QueryCodeNoKey
+		return
+	}
+
+	var key struct {
+		Base  uint32 `json:"base"`
+		Quote uint32 `json:"quote"`
+		Depth int    `json:"depth"`
+	}
+	err := json.Unmarshal(queryData, &key)
+	if err != nil {
+		res.Log = "error: unmarshal"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+	if key.Depth <= 0 || key.Depth > maxQueryPageSize {
+		key.Depth = maxQueryPageSize
+	}
+
+	depth := s.GetOrderBookDepth(key.Base, key.Quote, key.Depth, true)
+
+	jsonstr, _ := json.Marshal(depth)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = queryData
+
+	return
+}
+
+func queryOrders(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
+	if len(queryData) == 0 {
+		res.Log = "error: no query_data"
+		res.Code = code.QueryCodeNoKey
+		return
+	}
+
+	var key struct {
+		Owner crypto.Address `json:"owner"`
+		From  uint64         `json:"from"`
+		Num   int            `json:"num"`
+	}
+	err := json.Unmarshal(queryData, &key)
+	if err != nil {
+		res.Log = "error: unmarshal"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+	if len(key.Owner) == 0 {
+		res.Log = "error: owner is missing"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+	if key.Num <= 0 || key.Num > maxQueryPageSize {
+		key.Num = maxQueryPageSize
+	}
+
+	orders, next := s.GetOrdersByOwner(key.Owner, key.From, key.Num, true)
+
+	jsonstr, _ := json.Marshal(types.OrderList{
+		Orders: orders,
+		Next:   next,
+	})
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = queryData
+
+	return
+}
+
+func querySchedule(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
+	if len(queryData) == 0 {
+		res.Log = "error: no query_data"
+		res.Code = code.QueryCodeNoKey
+		return
+	}
+
+	var id uint64
+	err := json.Unmarshal(queryData, &id)
+	if err != nil {
+		res.Log = "error: unmarshal"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+
+	schedule := s.GetSchedule(id, true)
+	if schedule == nil {
+		res.Log = "error: no such schedule"
+		res.Code = code.QueryCodeNoMatch
+		return
+	}
+
+	jsonstr, _ := json.Marshal(types.ScheduleEx{ID: id, Schedule: schedule})
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = queryData
+
+	return
+}
+
+func queryChannel(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
+	if len(queryData) == 0 {
+		res.Log = "error: no query_data"
+		res.Code = code.QueryCodeNoKey
+		return
+	}
+
+	var id uint64
+	err := json.Unmarshal(queryData, &id)
+	if err != nil {
+		res.Log = "error: unmarshal"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+
+	channel := s.GetChannel(id, true)
+	if channel == nil {
+		res.Log = "error: no such channel"
+		res.Code = code.QueryCodeNoMatch
+		return
+	}
+
+	jsonstr, _ := json.Marshal(types.ChannelEx{ID: id, Channel: channel})
 	res.Log = string(jsonstr)
 	res.Value = jsonstr
 	res.Code = code.QueryCodeOK
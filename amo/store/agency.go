@@ -0,0 +1,80 @@
+package store
+
+import (
+	"bytes"
+
+	"github.com/tendermint/tendermint/crypto"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+func makeAgencyIndexKey(agency, recipient crypto.Address, parcelID []byte) []byte {
+	key := append([]byte{}, agency...)
+	key = append(key, recipient...)
+	return append(key, parcelID...)
+}
+
+// updateAgencyIndex keeps an index entry for a pair of recipient and parcel
+// as long as a request or a usage made by the agency exists for the pair.
+func (s *Store) updateAgencyIndex(agency, recipient crypto.Address, parcelID []byte) {
+	if len(agency) == 0 {
+		return
+	}
+	key := makeAgencyIndexKey(agency, recipient, parcelID)
+
+	request := s.GetRequest(recipient, parcelID, false)
+	usage := s.GetUsage(recipient, parcelID, false)
+	if (request != nil && bytes.Equal(request.Agency, agency)) ||
+		(usage != nil && bytes.Equal(usage.Agency, agency)) {
+		s.indexAgency.Set(key, nil)
+		return
+	}
+	s.indexAgency.Delete(key)
+}
+
+// GetAgencyEntries returns requests and usages made by an agency on behalf of
+// recipients.
+func (s *Store) GetAgencyEntries(agency crypto.Address, committed bool) (
+	requests []*types.RequestEx, usages []*types.UsageEx) {
+	requests = []*types.RequestEx{}
+	usages = []*types.UsageEx{}
+
+	itr, err := s.indexAgency.Iterator(agency, nil)
+	if err != nil {
+		s.logger.Error("Store", "GetAgencyEntries", err.Error())
+		return
+	}
+	defer itr.Close()
+
+	pos := len(agency) + crypto.AddressSize
+	for ; itr.Valid() && bytes.HasPrefix(itr.Key(), agency); itr.Next() {
+		key := itr.Key()
+		if len(key) <= pos {
+			continue
+		}
+		recipient := make(crypto.Address, crypto.AddressSize)
+		copy(recipient, key[len(agency):pos])
+		parcelID := make(tmbytes.HexBytes, len(key)-pos)
+		copy(parcelID, key[pos:])
+
+		request := s.GetRequest(recipient, parcelID, committed)
+		if request != nil && bytes.Equal(request.Agency, agency) {
+			requests = append(requests, &types.RequestEx{
+				Request:   request,
+				Recipient: recipient,
+				Target:    parcelID,
+			})
+		}
+		usage := s.GetUsage(recipient, parcelID, committed)
+		if usage != nil && bytes.Equal(usage.Agency, agency) {
+			usages = append(usages, &types.UsageEx{
+				Usage:     usage,
+				Recipient: recipient,
+				Target:    parcelID,
+			})
+		}
+	}
+
+	return
+}
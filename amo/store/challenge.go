@@ -0,0 +1,227 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+var (
+	prefixChallenge       = []byte("challenge:")
+	prefixMissedChallenge = []byte("challengemiss:")
+	// challengedue:deadline:parcel, for open challenges
+	prefixChallengeDue = []byte("challengedue:")
+	// challengelast:parcel, deadline of the last challenge
+	prefixChallengeLast = []byte("challengelast:")
+)
+
+func getChallengeKey(parcelID []byte) []byte {
+	key := append([]byte{}, prefixChallenge...)
+	return append(key, parcelID...)
+}
+
+func getChallengeDueKey(deadline int64, parcelID []byte) []byte {
+	key := append([]byte{}, prefixChallengeDue...)
+	key = append(key, convUint64(uint64(deadline))...)
+	return append(key, parcelID...)
+}
+
+func getChallengeLastKey(parcelID []byte) []byte {
+	key := append([]byte{}, prefixChallengeLast...)
+	return append(key, parcelID...)
+}
+
+// key = prefix + storage id + deadline + parcel id
+func getMissedChallengeKey(parcelID []byte, deadline int64) []byte {
+	hb := make([]byte, 8)
+	binary.BigEndian.PutUint64(hb, uint64(deadline))
+	key := append([]byte{}, prefixMissedChallenge...)
+	key = append(key, parcelID[:types.StorageIDLen]...)
+	key = append(key, hb...)
+	key = append(key, parcelID...)
+	return key
+}
+
+func (s Store) SetChallenge(parcelID []byte, challenge *types.Challenge) error {
+	b, err := json.Marshal(challenge)
+	if err != nil {
+		return err
+	}
+	if prev := s.GetChallenge(parcelID, false); prev != nil {
+		s.remove(getChallengeDueKey(prev.Deadline, parcelID))
+	}
+	s.set(getChallengeKey(parcelID), b)
+	s.set(getChallengeDueKey(challenge.Deadline, parcelID), []byte{})
+	s.set(getChallengeLastKey(parcelID),
+		convUint64(uint64(challenge.Deadline)))
+	return nil
+}
+
+func (s Store) GetChallenge(parcelID []byte, committed bool) *types.Challenge {
+	b := s.get(getChallengeKey(parcelID), committed)
+	if len(b) == 0 {
+		return nil
+	}
+	var challenge types.Challenge
+	err := json.Unmarshal(b, &challenge)
+	if err != nil {
+		return nil
+	}
+	return &challenge
+}
+
+func (s Store) DeleteChallenge(parcelID []byte) {
+	challenge := s.GetChallenge(parcelID, false)
+	if challenge == nil {
+		return
+	}
+	s.remove(getChallengeKey(parcelID))
+	s.remove(getChallengeDueKey(challenge.Deadline, parcelID))
+}
+
+// GetLastChallengeDeadline returns the deadline of the last challenge to the
+// parcel, whether answered or not, or 0 if never challenged.
+func (s Store) GetLastChallengeDeadline(parcelID []byte, committed bool) int64 {
+	b := s.get(getChallengeLastKey(parcelID), committed)
+	if len(b) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func (s Store) GetChallenges(committed bool) []*types.ChallengeEx {
+	challenges := []*types.ChallengeEx{}
+
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return challenges
+	}
+
+	imt.IterateRangeInclusive(prefixChallenge, nil, true, func(key []byte, value []byte, version int64) bool {
+		if !bytes.HasPrefix(key, prefixChallenge) {
+			return true
+		}
+		challenge := new(types.Challenge)
+		err := json.Unmarshal(value, challenge)
+		if err != nil {
+			return false // continue
+		}
+		parcelID := make([]byte, len(key)-len(prefixChallenge))
+		copy(parcelID, key[len(prefixChallenge):])
+		challenges = append(challenges, &types.ChallengeEx{
+			Challenge: challenge,
+			Parcel:    parcelID,
+		})
+		return false
+	})
+
+	return challenges
+}
+
+func (s Store) AddMissedChallenge(parcelID []byte, challenge *types.Challenge) error {
+	b, err := json.Marshal(challenge)
+	if err != nil {
+		return err
+	}
+	s.set(getMissedChallengeKey(parcelID, challenge.Deadline), b)
+	return nil
+}
+
+// GetMissedChallenges returns challenges missed by a storage in ascending
+// order of their deadlines.
+func (s Store) GetMissedChallenges(storageID uint32, committed bool) []*types.ChallengeEx {
+	challenges := []*types.ChallengeEx{}
+
+	prefix := append([]byte{}, prefixMissedChallenge...)
+	prefix = append(prefix, ConvIDFromUint(storageID)...)
+	pos := len(prefix) + 8 // skip deadline
+
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return challenges
+	}
+
+	imt.IterateRangeInclusive(prefix, nil, true, func(key []byte, value []byte, version int64) bool {
+		if !bytes.HasPrefix(key, prefix) {
+			return true
+		}
+		if len(key) <= pos {
+			return false // continue
+		}
+		challenge := new(types.Challenge)
+		err := json.Unmarshal(value, challenge)
+		if err != nil {
+			return false // continue
+		}
+		parcelID := make([]byte, len(key)-pos)
+		copy(parcelID, key[pos:])
+		challenges = append(challenges, &types.ChallengeEx{
+			Challenge: challenge,
+			Parcel:    parcelID,
+		})
+		return false
+	})
+
+	return challenges
+}
+
+// ExpireChallenges records the challenges not answered until *height* as
+// missed ones, returns the deposits to the challengers, and slashes
+// *slashRatio* of the bond of the storage which missed the challenge.
+func (s Store) ExpireChallenges(height int64, slashRatio float64, committed bool) []abci.Event {
+	events := []abci.Event{}
+
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return events
+	}
+
+	var parcels [][]byte
+	pos := len(prefixChallengeDue) + 8
+	end := append([]byte{}, prefixChallengeDue...)
+	end = append(end, convUint64(uint64(height+1))...)
+	imt.IterateRange(prefixChallengeDue, end, true,
+		func(key []byte, value []byte) bool {
+			if len(key) > pos {
+				parcels = append(parcels, append([]byte{}, key[pos:]...))
+			}
+			return false
+		},
+	)
+
+	for _, parcelID := range parcels {
+		c := s.GetChallenge(parcelID, false)
+		if c == nil {
+			continue
+		}
+		s.DeleteChallenge(parcelID)
+		s.AddMissedChallenge(parcelID, c)
+		if c.Deposit.GreaterThan(types.Zero) {
+			s.addCoin(0, c.Challenger, &c.Deposit)
+		}
+
+		storageID := binary.BigEndian.Uint32(parcelID[:types.StorageIDLen])
+		idJson, _ := json.Marshal(storageID)
+		parcelJson, _ := json.Marshal(tmbytes.HexBytes(parcelID))
+		events = append(events, abci.Event{
+			Type: "challenge_miss",
+			Attributes: []kv.Pair{
+				{Key: []byte("storage"), Value: idJson},
+				{Key: []byte("parcel"), Value: parcelJson},
+			},
+		})
+
+		if slashRatio > 0 {
+			evs := s.SlashStorage(storageID, slashRatio, committed)
+			events = append(events, evs...)
+		}
+	}
+
+	return events
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+var (
+	// channel:id
+	prefixChannel = []byte("channel:")
+	// channelend:end:id, for closing channels
+	prefixChannelEnd = []byte("channelend:")
+
+	channelSeqKey = []byte("channelseq")
+)
+
+func getChannelKey(id uint64) []byte {
+	key := append([]byte{}, prefixChannel...)
+	return append(key, convUint64(id)...)
+}
+
+func getChannelEndKey(end int64, id uint64) []byte {
+	key := append([]byte{}, prefixChannelEnd...)
+	key = append(key, convUint64(uint64(end))...)
+	return append(key, convUint64(id)...)
+}
+
+func (s Store) NextChannelID() uint64 {
+	var id uint64
+	b := s.get(channelSeqKey, false)
+	if len(b) == 8 {
+		id = binary.BigEndian.Uint64(b)
+	}
+	id++
+	s.set(channelSeqKey, convUint64(id))
+	return id
+}
+
+func (s Store) SetChannel(id uint64, channel *types.Channel) error {
+	b, err := json.Marshal(channel)
+	if err != nil {
+		return err
+	}
+	if prev := s.GetChannel(id, false); prev != nil && prev.Closing() {
+		s.remove(getChannelEndKey(prev.End, id))
+	}
+	s.set(getChannelKey(id), b)
+	if channel.Closing() {
+		s.set(getChannelEndKey(channel.End, id), []byte{})
+	}
+	return nil
+}
+
+func (s Store) GetChannel(id uint64, committed bool) *types.Channel {
+	b := s.get(getChannelKey(id), committed)
+	if len(b) == 0 {
+		return nil
+	}
+	var channel types.Channel
+	err := json.Unmarshal(b, &channel)
+	if err != nil {
+		return nil
+	}
+	return &channel
+}
+
+func (s Store) DeleteChannel(id uint64) {
+	channel := s.GetChannel(id, false)
+	if channel == nil {
+		return
+	}
+	s.remove(getChannelKey(id))
+	if channel.Closing() {
+		s.remove(getChannelEndKey(channel.End, id))
+	}
+}
+
+// SettleChannels pays the channels whose challenge period ended until
+// *height*, and then removes them. A channel paying a frozen recipient is left
+// to be settled after unfrozen, while the refund to the sender is made
+// regardless of freezing, leaving only the payment in the channel.
+func (s Store) SettleChannels(height int64, committed bool) []abci.Event {
+	events := []abci.Event{}
+
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return events
+	}
+
+	var ids []uint64
+	end := getChannelEndKey(height, ^uint64(0))
+	imt.IterateRangeInclusive(prefixChannelEnd, end, true,
+		func(key []byte, value []byte, version int64) bool {
+			if !bytes.HasPrefix(key, prefixChannelEnd) {
+				return true
+			}
+			ids = append(ids, binary.BigEndian.Uint64(key[len(key)-8:]))
+			return false
+		},
+	)
+
+	for _, id := range ids {
+		channel := s.GetChannel(id, false)
+		if channel == nil {
+			continue
+		}
+		// the voucher was checked on submission, but never pay out more
+		// than the deposit nor less than nothing
+		if channel.Paid.Sign() < 0 {
+			channel.Paid.Int.SetInt64(0)
+		}
+		if channel.Paid.GreaterThan(&channel.Deposit) {
+			channel.Paid.Int.Set(&channel.Deposit.Int)
+		}
+		refund := new(types.Currency)
+		refund.Int.Sub(&channel.Deposit.Int, &channel.Paid.Int)
+		if refund.GreaterThan(types.Zero) {
+			s.addCoin(channel.UDC, channel.Sender, refund)
+		}
+		idJson, _ := json.Marshal(id)
+		refundJson, _ := json.Marshal(refund)
+
+		// the payment to a frozen recipient is held until unfrozen
+		if channel.Paid.GreaterThan(types.Zero) &&
+			s.isUDCFrozenFor(channel.UDC, channel.Recipient) {
+			if refund.GreaterThan(types.Zero) {
+				channel.Deposit.Int.Set(&channel.Paid.Int)
+				s.SetChannel(id, channel)
+				events = append(events, abci.Event{
+					Type: "channel_refund",
+					Attributes: []kv.Pair{
+						{Key: []byte("id"), Value: idJson},
+						{Key: []byte("refund"), Value: refundJson},
+					},
+				})
+			}
+			continue
+		}
+
+		if channel.Paid.GreaterThan(types.Zero) {
+			s.addCoin(channel.UDC, channel.Recipient, &channel.Paid)
+		}
+		s.DeleteChannel(id)
+
+		paidJson, _ := json.Marshal(channel.Paid)
+		events = append(events, abci.Event{
+			Type: "channel_settle",
+			Attributes: []kv.Pair{
+				{Key: []byte("id"), Value: idJson},
+				{Key: []byte("paid"), Value: paidJson},
+				{Key: []byte("refund"), Value: refundJson},
+			},
+		})
+	}
+
+	return events
+}
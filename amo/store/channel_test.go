@@ -0,0 +1,113 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	tmdb "github.com/tendermint/tm-db"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+func TestSettleChannels(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	alice := makeAccAddr("alice")
+	bob := makeAccAddr("bob")
+
+	open := s.NextChannelID()
+	s.SetChannel(open, &types.Channel{
+		Sender:    alice,
+		Recipient: bob,
+		Deposit:   *new(types.Currency).Set(100),
+		Period:    10,
+	})
+	closing := s.NextChannelID()
+	s.SetChannel(closing, &types.Channel{
+		Sender:    alice,
+		Recipient: bob,
+		Deposit:   *new(types.Currency).Set(100),
+		Period:    10,
+		Paid:      *new(types.Currency).Set(30),
+		End:       20,
+	})
+	// challenged, and thus moved to a later end
+	challenged := s.NextChannelID()
+	s.SetChannel(challenged, &types.Channel{
+		Sender:    alice,
+		Recipient: bob,
+		Deposit:   *new(types.Currency).Set(100),
+		Period:    10,
+		End:       20,
+	})
+	channel := s.GetChannel(challenged, false)
+	channel.End = 25
+	channel.Paid.Set(1000) // more than the deposit
+	s.SetChannel(challenged, channel)
+
+	assert.Equal(t, 0, len(s.SettleChannels(19, false)))
+
+	events := s.SettleChannels(20, false)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "channel_settle", events[0].Type)
+	assert.Nil(t, s.GetChannel(closing, false))
+	assert.NotNil(t, s.GetChannel(challenged, false))
+	assert.Equal(t, new(types.Currency).Set(30), s.GetBalance(bob, false))
+	assert.Equal(t, new(types.Currency).Set(70), s.GetBalance(alice, false))
+
+	events = s.SettleChannels(25, false)
+	assert.Equal(t, 1, len(events))
+	assert.Nil(t, s.GetChannel(challenged, false))
+	assert.Equal(t, new(types.Currency).Set(130), s.GetBalance(bob, false))
+	assert.Equal(t, new(types.Currency).Set(70), s.GetBalance(alice, false))
+
+	// payment to frozen recipient is held
+	s.SetUDC(123, &types.UDC{Total: *new(types.Currency).Set(1000)})
+	frozen := s.NextChannelID()
+	s.SetChannel(frozen, &types.Channel{
+		Sender:    alice,
+		Recipient: bob,
+		UDC:       123,
+		Deposit:   *new(types.Currency).Set(100),
+		Period:    10,
+		Paid:      *new(types.Currency).Set(40),
+		End:       26,
+	})
+	s.SetUDCFrozen(123, bob, true)
+	// but the sender gets the refund
+	events = s.SettleChannels(26, false)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "channel_refund", events[0].Type)
+	assert.Equal(t, new(types.Currency).Set(60),
+		s.GetUDCBalance(123, alice, false))
+	channel = s.GetChannel(frozen, false)
+	assert.NotNil(t, channel)
+	assert.Equal(t, new(types.Currency).Set(40), &channel.Deposit)
+	assert.Equal(t, 0, len(s.SettleChannels(26, false)))
+	s.SetUDCFrozen(123, bob, false)
+	assert.Equal(t, 1, len(s.SettleChannels(27, false)))
+	assert.Equal(t, new(types.Currency).Set(40), s.GetUDCBalance(123, bob, false))
+	assert.Equal(t, new(types.Currency).Set(60),
+		s.GetUDCBalance(123, alice, false))
+
+	// never settled while open
+	assert.Equal(t, 0, len(s.SettleChannels(1000, false)))
+	assert.NotNil(t, s.GetChannel(open, false))
+
+	// negative voucher is not paid
+	negative := s.NextChannelID()
+	s.SetChannel(negative, &types.Channel{
+		Sender:    alice,
+		Recipient: bob,
+		Deposit:   *new(types.Currency).Set(100),
+		Period:    10,
+		End:       30,
+	})
+	channel = s.GetChannel(negative, false)
+	channel.Paid.Int.SetInt64(-50)
+	s.SetChannel(negative, channel)
+	s.SettleChannels(30, false)
+	assert.Equal(t, new(types.Currency).Set(130), s.GetBalance(bob, false))
+	assert.Equal(t, new(types.Currency).Set(170), s.GetBalance(alice, false))
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"encoding/json"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+var (
+	prefixCredential = []byte("credential:")
+)
+
+// DID never contains a null byte, so it is safe to use it as a separator
+func getCredentialKey(issuer, credential string) []byte {
+	key := append([]byte{}, prefixCredential...)
+	key = append(key, []byte(issuer)...)
+	key = append(key, 0x00)
+	return append(key, []byte(credential)...)
+}
+
+func (s Store) SetCredentialStatus(issuer, credential string,
+	status *types.CredentialStatus) error {
+	b, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	s.set(getCredentialKey(issuer, credential), b)
+	return nil
+}
+
+func (s Store) GetCredentialStatus(issuer, credential string,
+	committed bool) *types.CredentialStatus {
+	b := s.get(getCredentialKey(issuer, credential), committed)
+	if len(b) == 0 {
+		return nil
+	}
+	var status types.CredentialStatus
+	err := json.Unmarshal(b, &status)
+	if err != nil {
+		return nil
+	}
+	return &status
+}
+
+func (s Store) DeleteCredentialStatus(issuer, credential string) {
+	s.remove(getCredentialKey(issuer, credential))
+}
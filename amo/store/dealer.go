@@ -0,0 +1,40 @@
+package store
+
+import (
+	"encoding/json"
+
+	"github.com/tendermint/tendermint/crypto"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+var (
+	prefixDealer = []byte("dealer:")
+)
+
+func getDealerKey(addr crypto.Address) []byte {
+	key := append([]byte{}, prefixDealer...)
+	return append(key, addr...)
+}
+
+func (s Store) SetDealer(addr crypto.Address, dealer *types.Dealer) error {
+	b, err := json.Marshal(dealer)
+	if err != nil {
+		return err
+	}
+	s.set(getDealerKey(addr), b)
+	return nil
+}
+
+func (s Store) GetDealer(addr crypto.Address, committed bool) *types.Dealer {
+	b := s.get(getDealerKey(addr), committed)
+	if len(b) == 0 {
+		return nil
+	}
+	var dealer types.Dealer
+	err := json.Unmarshal(b, &dealer)
+	if err != nil {
+		return nil
+	}
+	return &dealer
+}
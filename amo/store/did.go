@@ -1,25 +1,47 @@
 package store
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
 
 	"github.com/amolabs/amoabci/amo/types"
 )
 
 var (
-	prefixDID = []byte("did:")
+	prefixDID        = []byte("did:")
+	prefixDIDVersion = []byte("didversion:")
 )
 
 func makeDIDKey(did string) []byte {
 	return append(prefixDID, []byte(did)...)
 }
 
+// DID never contains a null byte, so it is safe to use it as a separator
+func makeDIDVersionPrefix(did string) []byte {
+	key := append([]byte{}, prefixDIDVersion...)
+	key = append(key, []byte(did)...)
+	return append(key, 0x00)
+}
+
+func makeDIDVersionKey(did string, version uint64) []byte {
+	ver := make([]byte, 8)
+	binary.BigEndian.PutUint64(ver, version)
+	return append(makeDIDVersionPrefix(did), ver...)
+}
+
+// SetDIDEntry stores the entry and keeps a copy of it as a history record for
+// the version of the entry. Entries set before protocol v6 have no version and
+// no history.
 func (s Store) SetDIDEntry(id string, value *types.DIDEntry) error {
 	b, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
 	s.set(makeDIDKey(id), b)
+	if value.Version > 0 {
+		s.set(makeDIDVersionKey(id, value.Version), b)
+	}
 	return nil
 }
 
@@ -36,6 +58,68 @@ func (s Store) GetDIDEntry(id string, committed bool) *types.DIDEntry {
 	return &entry
 }
 
+func (s Store) GetDIDEntryVersion(id string, version uint64, committed bool) *types.DIDEntry {
+	b := s.get(makeDIDVersionKey(id, version), committed)
+	if len(b) == 0 {
+		return nil
+	}
+	var entry types.DIDEntry
+	err := json.Unmarshal(b, &entry)
+	if err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// GetDIDEntryAt returns the latest version of the entry as of the given block
+// height.
+func (s Store) GetDIDEntryAt(id string, height int64, committed bool) *types.DIDEntry {
+	var entry *types.DIDEntry
+	s.iterateDIDVersions(id, committed, func(e *types.DIDEntry) bool {
+		if e.VersionTime() > height {
+			return true
+		}
+		entry = e
+		return false
+	})
+	return entry
+}
+
+func (s Store) GetDIDVersions(id string, committed bool) []*types.DIDEntry {
+	entries := []*types.DIDEntry{}
+	s.iterateDIDVersions(id, committed, func(e *types.DIDEntry) bool {
+		entries = append(entries, e)
+		return false
+	})
+	return entries
+}
+
+func (s Store) iterateDIDVersions(id string, committed bool,
+	fn func(entry *types.DIDEntry) bool) {
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return
+	}
+
+	prefix := makeDIDVersionPrefix(id)
+	imt.IterateRangeInclusive(prefix, nil, true,
+		func(key []byte, value []byte, version int64) bool {
+			if !bytes.HasPrefix(key, prefix) {
+				return true
+			}
+			var entry types.DIDEntry
+			err := json.Unmarshal(value, &entry)
+			if err != nil {
+				return false
+			}
+			return fn(&entry)
+		},
+	)
+}
+
 func (s Store) DeleteDIDEntry(id string) {
 	s.remove(makeDIDKey(id))
+	for _, entry := range s.GetDIDVersions(id, false) {
+		s.remove(makeDIDVersionKey(id, entry.Version))
+	}
 }
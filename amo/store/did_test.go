@@ -27,3 +27,44 @@ func TestDIDGetSet(t *testing.T) {
 	assert.Equal(t, makeAccAddr("me"), _entry.Owner)
 	assert.True(t, bytes.Equal(jsonDoc, _entry.Document))
 }
+
+func TestDIDVersions(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	entry := &types.DIDEntry{
+		Owner:    makeAccAddr("me"),
+		Document: []byte(`{"v":1}`),
+		Created:  10,
+		Version:  1,
+	}
+	s.SetDIDEntry("did:amo:myid", entry)
+	entry.Document = []byte(`{"v":2}`)
+	entry.Updated = 20
+	entry.Version = 2
+	s.SetDIDEntry("did:amo:myid", entry)
+	// another DID sharing the prefix
+	s.SetDIDEntry("did:amo:myid2", &types.DIDEntry{
+		Owner:    makeAccAddr("me"),
+		Document: []byte(`{"v":1}`),
+		Created:  15,
+		Version:  1,
+	})
+
+	assert.Equal(t, 2, len(s.GetDIDVersions("did:amo:myid", false)))
+	assert.Equal(t, []byte(`{"v":2}`),
+		[]byte(s.GetDIDEntry("did:amo:myid", false).Document))
+	assert.Equal(t, []byte(`{"v":1}`),
+		[]byte(s.GetDIDEntryVersion("did:amo:myid", 1, false).Document))
+	assert.Nil(t, s.GetDIDEntryVersion("did:amo:myid", 3, false))
+
+	assert.Nil(t, s.GetDIDEntryAt("did:amo:myid", 9, false))
+	assert.Equal(t, uint64(1), s.GetDIDEntryAt("did:amo:myid", 19, false).Version)
+	assert.Equal(t, uint64(2), s.GetDIDEntryAt("did:amo:myid", 20, false).Version)
+	assert.Equal(t, uint64(2), s.GetDIDEntryAt("did:amo:myid", 99, false).Version)
+
+	s.DeleteDIDEntry("did:amo:myid")
+	assert.Nil(t, s.GetDIDEntry("did:amo:myid", false))
+	assert.Equal(t, 0, len(s.GetDIDVersions("did:amo:myid", false)))
+	assert.Equal(t, 1, len(s.GetDIDVersions("did:amo:myid2", false)))
+}
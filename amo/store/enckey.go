@@ -0,0 +1,44 @@
+package store
+
+import (
+	"encoding/json"
+
+	"github.com/tendermint/tendermint/crypto"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+var (
+	prefixEncKey = []byte("enckey:")
+)
+
+func getEncKeyKey(addr crypto.Address) []byte {
+	key := append([]byte{}, prefixEncKey...)
+	return append(key, addr...)
+}
+
+func (s Store) SetEncKey(addr crypto.Address, encKey *types.EncKey) error {
+	if len(encKey.Key) == 0 {
+		s.remove(getEncKeyKey(addr))
+		return nil
+	}
+	b, err := json.Marshal(encKey)
+	if err != nil {
+		return err
+	}
+	s.set(getEncKeyKey(addr), b)
+	return nil
+}
+
+func (s Store) GetEncKey(addr crypto.Address, committed bool) *types.EncKey {
+	b := s.get(getEncKeyKey(addr), committed)
+	if len(b) == 0 {
+		return nil
+	}
+	var encKey types.EncKey
+	err := json.Unmarshal(b, &encKey)
+	if err != nil {
+		return nil
+	}
+	return &encKey
+}
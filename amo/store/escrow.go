@@ -0,0 +1,136 @@
+package store
+
+import (
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+var (
+	prefixEscrow = []byte("escrow:")
+	// escrowdue:end:recipient:parcel, for escrows not disputed
+	prefixEscrowDue = []byte("escrowdue:")
+)
+
+// key = prefix + recipient + parcel id
+func getEscrowKey(recipient crypto.Address, parcelID []byte) []byte {
+	key := append([]byte{}, prefixEscrow...)
+	key = append(key, recipient...)
+	return append(key, parcelID...)
+}
+
+func getEscrowDueKey(end int64, recipient crypto.Address, parcelID []byte) []byte {
+	key := append([]byte{}, prefixEscrowDue...)
+	key = append(key, convUint64(uint64(end))...)
+	key = append(key, recipient...)
+	return append(key, parcelID...)
+}
+
+func (s Store) SetEscrow(recipient crypto.Address, parcelID []byte, escrow *types.Escrow) error {
+	b, err := json.Marshal(escrow)
+	if err != nil {
+		return err
+	}
+	if prev := s.GetEscrow(recipient, parcelID, false); prev != nil {
+		s.remove(getEscrowDueKey(prev.End, recipient, parcelID))
+	}
+	s.set(getEscrowKey(recipient, parcelID), b)
+	// disputed ones are left for the arbiters
+	if !escrow.Disputed {
+		s.set(getEscrowDueKey(escrow.End, recipient, parcelID), []byte{})
+	}
+	return nil
+}
+
+func (s Store) GetEscrow(recipient crypto.Address, parcelID []byte, committed bool) *types.Escrow {
+	b := s.get(getEscrowKey(recipient, parcelID), committed)
+	if len(b) == 0 {
+		return nil
+	}
+	var escrow types.Escrow
+	err := json.Unmarshal(b, &escrow)
+	if err != nil {
+		return nil
+	}
+	return &escrow
+}
+
+func (s Store) DeleteEscrow(recipient crypto.Address, parcelID []byte) {
+	escrow := s.GetEscrow(recipient, parcelID, false)
+	if escrow == nil {
+		return
+	}
+	s.remove(getEscrowKey(recipient, parcelID))
+	s.remove(getEscrowDueKey(escrow.End, recipient, parcelID))
+}
+
+// SettleEscrow pays *refund* of the escrow back to the payer and the rest to
+// the payee, and then removes the escrow.
+func (s Store) SettleEscrow(recipient crypto.Address, parcelID []byte,
+	escrow *types.Escrow, refund *types.Currency) {
+	paid := new(types.Currency).Set(0)
+	paid.Add(&escrow.Amount)
+	paid.Sub(refund)
+
+	balance := s.GetBalance(escrow.Payer, false)
+	balance.Add(refund)
+	s.SetBalance(escrow.Payer, balance)
+	balance = s.GetBalance(escrow.Payee, false)
+	balance.Add(paid)
+	s.SetBalance(escrow.Payee, balance)
+
+	s.DeleteEscrow(recipient, parcelID)
+}
+
+// ReleaseEscrows pays the escrows whose hold period ended until *height* to
+// their payees. Disputed escrows are left for the arbiters.
+func (s Store) ReleaseEscrows(height int64, committed bool) []abci.Event {
+	events := []abci.Event{}
+
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return events
+	}
+
+	var keys [][]byte
+	pos := len(prefixEscrowDue) + 8
+	end := append([]byte{}, prefixEscrowDue...)
+	end = append(end, convUint64(uint64(height+1))...)
+	imt.IterateRange(prefixEscrowDue, end, true,
+		func(key []byte, value []byte) bool {
+			if len(key) > pos+crypto.AddressSize {
+				keys = append(keys, append([]byte{}, key[pos:]...))
+			}
+			return false
+		},
+	)
+
+	for _, key := range keys {
+		recipient := crypto.Address(key[:crypto.AddressSize])
+		parcelID := key[crypto.AddressSize:]
+		escrow := s.GetEscrow(recipient, parcelID, false)
+		if escrow == nil || escrow.Disputed {
+			continue
+		}
+		s.SettleEscrow(recipient, parcelID, escrow, types.Zero)
+
+		recipientJson, _ := json.Marshal(recipient)
+		targetJson, _ := json.Marshal(tmbytes.HexBytes(parcelID))
+		amountJson, _ := json.Marshal(escrow.Amount)
+		events = append(events, abci.Event{
+			Type: "escrow_release",
+			Attributes: []kv.Pair{
+				{Key: []byte("recipient"), Value: recipientJson},
+				{Key: []byte("target"), Value: targetJson},
+				{Key: []byte("amount"), Value: amountJson},
+			},
+		})
+	}
+
+	return events
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"encoding/json"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+var (
+	prefixHTLC = []byte("htlc:")
+)
+
+func getHTLCKey(id []byte) []byte {
+	key := append([]byte{}, prefixHTLC...)
+	return append(key, id...)
+}
+
+func (s Store) SetHTLC(id []byte, htlc *types.HTLC) error {
+	b, err := json.Marshal(htlc)
+	if err != nil {
+		return err
+	}
+	s.set(getHTLCKey(id), b)
+	return nil
+}
+
+func (s Store) GetHTLC(id []byte, committed bool) *types.HTLC {
+	b := s.get(getHTLCKey(id), committed)
+	if len(b) == 0 {
+		return nil
+	}
+	var htlc types.HTLC
+	err := json.Unmarshal(b, &htlc)
+	if err != nil {
+		return nil
+	}
+	return &htlc
+}
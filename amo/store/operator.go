@@ -0,0 +1,86 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/tendermint/tendermint/crypto"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+var (
+	prefixOperator = []byte("operator:")
+)
+
+// key = prefix + parcel id + ':' + operator address
+func getOperatorKey(parcelID []byte, addr crypto.Address) []byte {
+	key := append([]byte{}, prefixOperator...)
+	key = append(key, parcelID...)
+	key = append(key, ':')
+	return append(key, addr...)
+}
+
+func (s Store) SetOperator(parcelID []byte, addr crypto.Address, op *types.Operator) error {
+	if op.Roles == 0 {
+		s.remove(getOperatorKey(parcelID, addr))
+		return nil
+	}
+	b, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	s.set(getOperatorKey(parcelID, addr), b)
+	return nil
+}
+
+func (s Store) GetOperator(parcelID []byte, addr crypto.Address, committed bool) *types.Operator {
+	b := s.get(getOperatorKey(parcelID, addr), committed)
+	if len(b) == 0 {
+		return nil
+	}
+	var op types.Operator
+	err := json.Unmarshal(b, &op)
+	if err != nil {
+		return nil
+	}
+	return &op
+}
+
+func (s Store) GetOperators(parcelID []byte, committed bool) []*types.OperatorEx {
+	ops := []*types.OperatorEx{}
+
+	prefix := append([]byte{}, prefixOperator...)
+	prefix = append(prefix, parcelID...)
+	prefix = append(prefix, ':')
+
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return ops
+	}
+
+	imt.IterateRangeInclusive(prefix, nil, true, func(key []byte, value []byte, version int64) bool {
+		if !bytes.HasPrefix(key, prefix) {
+			return true
+		}
+		// skip operators of other parcel whose id has this parcel id and ':'
+		// as its prefix
+		if len(key) != len(prefix)+crypto.AddressSize {
+			return false // continue
+		}
+		op := new(types.Operator)
+		err := json.Unmarshal(value, op)
+		if err != nil {
+			return false // continue
+		}
+		addr := make(crypto.Address, crypto.AddressSize)
+		copy(addr, key[len(prefix):])
+		ops = append(ops, &types.OperatorEx{
+			Operator: op,
+			Address:  addr,
+		})
+		return false
+	})
+
+	return ops
+}
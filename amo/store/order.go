@@ -0,0 +1,381 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+var (
+	// order:id
+	prefixOrder = []byte("order:")
+	// orderbook:base:quote:side:price:id
+	prefixOrderBook = []byte("orderbook:")
+	// orderowner:owner:id
+	prefixOrderOwner = []byte("orderowner:")
+	// ordermarket:base:quote, for the markets having new orders to match
+	prefixOrderMarket = []byte("ordermarket:")
+
+	orderSeqKey = []byte("orderseq")
+)
+
+func convUint64(id uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, id)
+	return b
+}
+
+func getOrderKey(id uint64) []byte {
+	key := append([]byte{}, prefixOrder...)
+	return append(key, convUint64(id)...)
+}
+
+func getOrderMarketKey(base, quote uint32) []byte {
+	key := append([]byte{}, prefixOrderMarket...)
+	key = append(key, ConvIDFromUint(base)...)
+	return append(key, ConvIDFromUint(quote)...)
+}
+
+func getOrderBookPrefix(base, quote uint32, side string) []byte {
+	key := append([]byte{}, prefixOrderBook...)
+	key = append(key, ConvIDFromUint(base)...)
+	key = append(key, ConvIDFromUint(quote)...)
+	return append(key, side[0])
+}
+
+// Orders in the book are sorted by price and then by id, which is the order of
+// placing them. Prices of buy orders are inverted to sort them from the
+// highest.
+func getOrderBookKey(id uint64, order *types.Order) []byte {
+	price := make([]byte, 32)
+	b := order.Price.Bytes()
+	copy(price[len(price)-len(b):], b)
+	if order.Side == types.OrderBuy {
+		for i := range price {
+			price[i] = ^price[i]
+		}
+	}
+	key := getOrderBookPrefix(order.Base, order.Quote, order.Side)
+	key = append(key, price...)
+	return append(key, convUint64(id)...)
+}
+
+func getOrderOwnerKey(owner crypto.Address, id uint64) []byte {
+	key := append([]byte{}, prefixOrderOwner...)
+	key = append(key, owner...)
+	return append(key, convUint64(id)...)
+}
+
+// NextOrderID returns an id for a new order, which increases for every order
+// placed to be used for time priority as well.
+func (s Store) NextOrderID() uint64 {
+	var id uint64
+	b := s.get(orderSeqKey, false)
+	if len(b) == 8 {
+		id = binary.BigEndian.Uint64(b)
+	}
+	id++
+	s.set(orderSeqKey, convUint64(id))
+	return id
+}
+
+func (s Store) SetOrder(id uint64, order *types.Order) error {
+	b, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+	s.set(getOrderKey(id), b)
+	s.set(getOrderBookKey(id, order), []byte{})
+	s.set(getOrderOwnerKey(order.Owner, id), []byte{})
+	return nil
+}
+
+func (s Store) GetOrder(id uint64, committed bool) *types.Order {
+	b := s.get(getOrderKey(id), committed)
+	if len(b) == 0 {
+		return nil
+	}
+	var order types.Order
+	err := json.Unmarshal(b, &order)
+	if err != nil {
+		return nil
+	}
+	return &order
+}
+
+func (s Store) DeleteOrder(id uint64) {
+	order := s.GetOrder(id, false)
+	if order == nil {
+		return
+	}
+	s.remove(getOrderKey(id))
+	s.remove(getOrderBookKey(id, order))
+	s.remove(getOrderOwnerKey(order.Owner, id))
+}
+
+// MarkOrderMarket lets the market be matched at the end of the block.
+func (s Store) MarkOrderMarket(base, quote uint32) {
+	s.set(getOrderMarketKey(base, quote), []byte{})
+}
+
+func (s Store) iterateOrderIDs(prefix []byte, committed bool,
+	fn func(id uint64) bool) {
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return
+	}
+	imt.IterateRangeInclusive(prefix, nil, true,
+		func(key []byte, value []byte, version int64) bool {
+			if !bytes.HasPrefix(key, prefix) {
+				return true
+			}
+			if len(key) < len(prefix)+8 {
+				return false
+			}
+			return fn(binary.BigEndian.Uint64(key[len(key)-8:]))
+		},
+	)
+}
+
+// GetOrderBook returns the orders of one side of the market in the order of
+// priority.
+func (s Store) GetOrderBook(base, quote uint32, side string,
+	committed bool) []*types.OrderEx {
+	orders := []*types.OrderEx{}
+	s.iterateOrderIDs(getOrderBookPrefix(base, quote, side), committed,
+		func(id uint64) bool {
+			order := s.GetOrder(id, committed)
+			if order != nil {
+				orders = append(orders, &types.OrderEx{ID: id, Order: order})
+			}
+			return false
+		})
+	return orders
+}
+
+// GetOrderBookDepth returns at most num price levels of each side of the
+// market.
+func (s Store) GetOrderBookDepth(base, quote uint32, num int,
+	committed bool) *types.OrderBookDepth {
+	depth := &types.OrderBookDepth{
+		Base:  base,
+		Quote: quote,
+		Bids:  []types.PriceLevel{},
+		Asks:  []types.PriceLevel{},
+	}
+	for _, side := range []string{types.OrderBuy, types.OrderSell} {
+		levels := []types.PriceLevel{}
+		s.iterateOrderIDs(getOrderBookPrefix(base, quote, side), committed,
+			func(id uint64) bool {
+				order := s.GetOrder(id, committed)
+				if order == nil {
+					return false
+				}
+				last := len(levels) - 1
+				if last >= 0 && levels[last].Price.Equals(&order.Price) {
+					levels[last].Amount.Add(&order.Amount)
+					return false
+				}
+				if len(levels) >= num {
+					return true
+				}
+				level := types.PriceLevel{}
+				level.Price.Int.Set(&order.Price.Int)
+				level.Amount.Int.Set(&order.Amount.Int)
+				levels = append(levels, level)
+				return false
+			})
+		if side == types.OrderBuy {
+			depth.Bids = levels
+		} else {
+			depth.Asks = levels
+		}
+	}
+	return depth
+}
+
+// GetOrdersByOwner returns at most num open orders of the owner, starting from
+// the order id given as from, and returns the id of the next order, if any.
+func (s Store) GetOrdersByOwner(owner crypto.Address, from uint64, num int,
+	committed bool) ([]*types.OrderEx, uint64) {
+	orders := []*types.OrderEx{}
+	var next uint64
+	prefix := append(append([]byte{}, prefixOrderOwner...), owner...)
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return orders, next
+	}
+	start := append(append([]byte{}, prefix...), convUint64(from)...)
+	imt.IterateRangeInclusive(start, nil, true,
+		func(key []byte, value []byte, version int64) bool {
+			if !bytes.HasPrefix(key, prefix) || len(key) != len(prefix)+8 {
+				return true
+			}
+			id := binary.BigEndian.Uint64(key[len(prefix):])
+			if len(orders) >= num {
+				next = id
+				return true
+			}
+			order := s.GetOrder(id, committed)
+			if order != nil {
+				orders = append(orders, &types.OrderEx{ID: id, Order: order})
+			}
+			return false
+		},
+	)
+	return orders, next
+}
+
+func (s Store) addCoin(udc uint32, holder crypto.Address, amount *types.Currency) {
+	if udc == 0 {
+		balance := s.GetBalance(holder, false)
+		balance.Add(amount)
+		s.SetBalance(holder, balance)
+		return
+	}
+	balance := s.GetUDCBalance(udc, holder, false)
+	balance.Add(amount)
+	s.SetUDCBalance(udc, holder, balance)
+}
+
+// closeOrder returns the rest of the escrow to the owner, even when frozen, and
+// removes the order.
+func (s Store) closeOrder(order *types.OrderEx) {
+	if order.Escrow.GreaterThan(types.Zero) {
+		s.addCoin(order.SellCoin(), order.Owner, &order.Escrow)
+	}
+	s.DeleteOrder(order.ID)
+}
+
+// MatchOrders matches buy and sell orders of the markets having new orders by
+// price-time priority. A trade is made at the price of the earlier order.
+// Orders which would pay a frozen holder are not filled until the market gets
+// matched again.
+func (s Store) MatchOrders(committed bool) []abci.Event {
+	events := []abci.Event{}
+
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return events
+	}
+
+	var markets [][]byte
+	imt.IterateRangeInclusive(prefixOrderMarket, nil, true,
+		func(key []byte, value []byte, version int64) bool {
+			if !bytes.HasPrefix(key, prefixOrderMarket) {
+				return true
+			}
+			markets = append(markets, key)
+			return false
+		},
+	)
+
+	pos := len(prefixOrderMarket)
+	for _, key := range markets {
+		s.remove(key)
+		if len(key) != pos+8 {
+			continue
+		}
+		base := binary.BigEndian.Uint32(key[pos : pos+4])
+		quote := binary.BigEndian.Uint32(key[pos+4:])
+		events = append(events, s.matchMarket(base, quote, committed)...)
+	}
+
+	return events
+}
+
+func (s Store) matchMarket(base, quote uint32, committed bool) []abci.Event {
+	events := []abci.Event{}
+
+	// fills are held while either coin is frozen as a whole
+	if s.isUDCFrozenFor(base, nil) || s.isUDCFrozenFor(quote, nil) {
+		return events
+	}
+
+	bids := s.GetOrderBook(base, quote, types.OrderBuy, committed)
+	asks := s.GetOrderBook(base, quote, types.OrderSell, committed)
+	i, j := 0, 0
+	for i < len(bids) && j < len(asks) {
+		bid, ask := bids[i], asks[j]
+		if bid.Price.LessThan(&ask.Price) {
+			break
+		}
+		// orders of frozen owners stay in the book without being filled
+		if s.isUDCFrozenFor(base, bid.Owner) {
+			i++
+			continue
+		}
+		if s.isUDCFrozenFor(quote, ask.Owner) {
+			j++
+			continue
+		}
+		price := new(types.Currency)
+		if bid.ID < ask.ID {
+			price.Int.Set(&bid.Price.Int)
+		} else {
+			price.Int.Set(&ask.Price.Int)
+		}
+		amount := new(types.Currency)
+		if ask.Amount.LessThan(&bid.Amount) {
+			amount.Int.Set(&ask.Amount.Int)
+		} else {
+			amount.Int.Set(&bid.Amount.Int)
+		}
+		paid := types.QuoteAmount(amount, price, false)
+		if !paid.GreaterThan(types.Zero) {
+			// the remainder is too small to be paid for at this price, so
+			// the dust order is closed without a fill
+			if bid.Amount.Equals(amount) {
+				s.closeOrder(bid)
+				i++
+			}
+			if ask.Amount.Equals(amount) {
+				s.closeOrder(ask)
+				j++
+			}
+			continue
+		}
+
+		bid.Amount.Sub(amount)
+		bid.Escrow.Sub(paid)
+		ask.Amount.Sub(amount)
+		ask.Escrow.Sub(amount)
+		s.addCoin(base, bid.Owner, amount)
+		s.addCoin(quote, ask.Owner, paid)
+
+		buyJson, _ := json.Marshal(bid.ID)
+		sellJson, _ := json.Marshal(ask.ID)
+		priceJson, _ := json.Marshal(price)
+		amountJson, _ := json.Marshal(amount)
+		events = append(events, abci.Event{
+			Type: "order_fill",
+			Attributes: []kv.Pair{
+				{Key: []byte("buy"), Value: buyJson},
+				{Key: []byte("sell"), Value: sellJson},
+				{Key: []byte("price"), Value: priceJson},
+				{Key: []byte("amount"), Value: amountJson},
+			},
+		})
+
+		if bid.Amount.Equals(types.Zero) {
+			s.closeOrder(bid)
+			i++
+		} else {
+			s.SetOrder(bid.ID, bid.Order)
+		}
+		if ask.Amount.Equals(types.Zero) {
+			s.closeOrder(ask)
+			j++
+		} else {
+			s.SetOrder(ask.ID, ask.Order)
+		}
+	}
+
+	return events
+}
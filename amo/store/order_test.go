@@ -0,0 +1,172 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	tmdb "github.com/tendermint/tm-db"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+func TestMatchOrders(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	alice := makeAccAddr("alice")
+	bob := makeAccAddr("bob")
+	carol := makeAccAddr("carol")
+	one := types.OneAMOUint64
+
+	// orders with escrows already taken
+	place := func(owner []byte, side string, price, amount uint64) uint64 {
+		order := &types.Order{
+			Owner:  owner,
+			Base:   123,
+			Quote:  0,
+			Side:   side,
+			Price:  *new(types.Currency).Set(price),
+			Amount: *new(types.Currency).Set(amount),
+		}
+		if side == types.OrderBuy {
+			order.Escrow = *types.QuoteAmount(&order.Amount, &order.Price, true)
+		} else {
+			order.Escrow = *new(types.Currency).Set(amount)
+		}
+		id := s.NextOrderID()
+		assert.NoError(t, s.SetOrder(id, order))
+		s.MarkOrderMarket(123, 0)
+		return id
+	}
+
+	sell1 := place(alice, types.OrderSell, 3*one, 100)
+	sell2 := place(alice, types.OrderSell, 2*one, 100)
+	buy1 := place(bob, types.OrderBuy, one, 100)
+	assert.Equal(t, uint64(3), buy1)
+
+	// not crossed
+	assert.Equal(t, 0, len(s.MatchOrders(false)))
+	depth := s.GetOrderBookDepth(123, 0, 10, false)
+	assert.Equal(t, 1, len(depth.Bids))
+	assert.Equal(t, 2, len(depth.Asks))
+	assert.Equal(t, *new(types.Currency).Set(2 * one), depth.Asks[0].Price)
+
+	// crosses both asks, at the prices of the asks
+	buy2 := place(carol, types.OrderBuy, 4*one, 150)
+	events := s.MatchOrders(false)
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, "order_fill", events[0].Type)
+	assert.Nil(t, s.GetOrder(sell2, false))
+	assert.Nil(t, s.GetOrder(buy2, false))
+	assert.Equal(t, *new(types.Currency).Set(50), s.GetOrder(sell1, false).Amount)
+	assert.Equal(t, new(types.Currency).Set(150),
+		s.GetUDCBalance(123, carol, false))
+	assert.Equal(t, new(types.Currency).Set(2*100+3*50),
+		s.GetBalance(alice, false))
+	// the rest of the escrow is returned
+	assert.Equal(t, new(types.Currency).Set(4*150-(2*100+3*50)),
+		s.GetBalance(carol, false))
+	assert.Equal(t, 0, len(s.MatchOrders(false)))
+
+	// a later ask trades at the price of the earlier bid
+	place(carol, types.OrderSell, one/2, 30)
+	events = s.MatchOrders(false)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, *new(types.Currency).Set(70), s.GetOrder(buy1, false).Amount)
+	assert.Equal(t, *new(types.Currency).Set(70), s.GetOrder(buy1, false).Escrow)
+	assert.Equal(t, new(types.Currency).Set(30), s.GetUDCBalance(123, bob, false))
+
+	orders, next := s.GetOrdersByOwner(alice, 0, 10, false)
+	assert.Equal(t, 1, len(orders))
+	assert.Equal(t, sell1, orders[0].ID)
+	assert.Equal(t, uint64(0), next)
+	s.DeleteOrder(sell1)
+	orders, _ = s.GetOrdersByOwner(alice, 0, 10, false)
+	assert.Equal(t, 0, len(orders))
+	assert.Equal(t, 0, len(s.GetOrderBookDepth(123, 0, 10, false).Asks))
+}
+
+func TestMatchOrdersDust(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	alice := makeAccAddr("alice")
+	bob := makeAccAddr("bob")
+	one := types.OneAMOUint64
+
+	// bid for 1 unit at half of the unit price pays nothing when filled
+	bid := s.NextOrderID()
+	s.SetOrder(bid, &types.Order{
+		Owner:  bob,
+		Base:   123,
+		Quote:  0,
+		Side:   types.OrderBuy,
+		Price:  *new(types.Currency).Set(one / 2),
+		Amount: *new(types.Currency).Set(1),
+		Escrow: *new(types.Currency).Set(1),
+	})
+	ask := s.NextOrderID()
+	s.SetOrder(ask, &types.Order{
+		Owner:  alice,
+		Base:   123,
+		Quote:  0,
+		Side:   types.OrderSell,
+		Price:  *new(types.Currency).Set(one / 2),
+		Amount: *new(types.Currency).Set(10),
+		Escrow: *new(types.Currency).Set(10),
+	})
+	s.MarkOrderMarket(123, 0)
+
+	assert.Equal(t, 0, len(s.MatchOrders(false)))
+	// the dust bid is closed and refunded, without getting the base coin
+	assert.Nil(t, s.GetOrder(bid, false))
+	assert.Equal(t, new(types.Currency).Set(1), s.GetBalance(bob, false))
+	assert.Equal(t, new(types.Currency).Set(0),
+		s.GetUDCBalance(123, bob, false))
+	assert.Equal(t, *new(types.Currency).Set(10), s.GetOrder(ask, false).Amount)
+}
+
+func TestMatchOrdersFrozen(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	alice := makeAccAddr("alice")
+	bob := makeAccAddr("bob")
+	one := types.OneAMOUint64
+	s.SetUDC(123, &types.UDC{Total: *new(types.Currency).Set(1000)})
+
+	place := func(owner []byte, side string) uint64 {
+		order := &types.Order{
+			Owner:  owner,
+			Base:   123,
+			Quote:  0,
+			Side:   side,
+			Price:  *new(types.Currency).Set(one),
+			Amount: *new(types.Currency).Set(10),
+			Escrow: *new(types.Currency).Set(10),
+		}
+		id := s.NextOrderID()
+		assert.NoError(t, s.SetOrder(id, order))
+		s.MarkOrderMarket(123, 0)
+		return id
+	}
+	bid := place(bob, types.OrderBuy)
+	ask := place(alice, types.OrderSell)
+
+	// the bid is held while its owner cannot get the base coin
+	s.SetUDCFrozen(123, bob, true)
+	assert.Equal(t, 0, len(s.MatchOrders(false)))
+	assert.NotNil(t, s.GetOrder(bid, false))
+	assert.NotNil(t, s.GetOrder(ask, false))
+
+	// and so is the market while the base coin is frozen as a whole
+	s.SetUDCFrozen(123, bob, false)
+	s.SetUDC(123, &types.UDC{Total: *new(types.Currency).Set(1000), Frozen: true})
+	s.MarkOrderMarket(123, 0)
+	assert.Equal(t, 0, len(s.MatchOrders(false)))
+
+	s.SetUDC(123, &types.UDC{Total: *new(types.Currency).Set(1000)})
+	s.MarkOrderMarket(123, 0)
+	assert.Equal(t, 1, len(s.MatchOrders(false)))
+	assert.Equal(t, new(types.Currency).Set(10), s.GetUDCBalance(123, bob, false))
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+var (
+	// schedule:id
+	prefixSchedule = []byte("schedule:")
+	// scheduledue:next:id
+	prefixScheduleDue = []byte("scheduledue:")
+	// schedulecount:sender, number of active schedules
+	prefixScheduleCount = []byte("schedulecount:")
+
+	scheduleSeqKey = []byte("scheduleseq")
+)
+
+func getScheduleKey(id uint64) []byte {
+	key := append([]byte{}, prefixSchedule...)
+	return append(key, convUint64(id)...)
+}
+
+func getScheduleDueKey(next int64, id uint64) []byte {
+	key := append([]byte{}, prefixScheduleDue...)
+	key = append(key, convUint64(uint64(next))...)
+	return append(key, convUint64(id)...)
+}
+
+func getScheduleCountKey(sender crypto.Address) []byte {
+	key := append([]byte{}, prefixScheduleCount...)
+	return append(key, sender...)
+}
+
+func (s Store) NextScheduleID() uint64 {
+	var id uint64
+	b := s.get(scheduleSeqKey, false)
+	if len(b) == 8 {
+		id = binary.BigEndian.Uint64(b)
+	}
+	id++
+	s.set(scheduleSeqKey, convUint64(id))
+	return id
+}
+
+func (s Store) SetSchedule(id uint64, schedule *types.Schedule) error {
+	b, err := json.Marshal(schedule)
+	if err != nil {
+		return err
+	}
+	if prev := s.GetSchedule(id, false); prev != nil {
+		s.remove(getScheduleDueKey(prev.Next, id))
+	} else {
+		s.setScheduleCount(schedule.Sender,
+			s.GetScheduleCount(schedule.Sender, false)+1)
+	}
+	s.set(getScheduleKey(id), b)
+	s.set(getScheduleDueKey(schedule.Next, id), []byte{})
+	return nil
+}
+
+func (s Store) GetSchedule(id uint64, committed bool) *types.Schedule {
+	b := s.get(getScheduleKey(id), committed)
+	if len(b) == 0 {
+		return nil
+	}
+	var schedule types.Schedule
+	err := json.Unmarshal(b, &schedule)
+	if err != nil {
+		return nil
+	}
+	return &schedule
+}
+
+func (s Store) DeleteSchedule(id uint64) {
+	schedule := s.GetSchedule(id, false)
+	if schedule == nil {
+		return
+	}
+	s.remove(getScheduleKey(id))
+	s.remove(getScheduleDueKey(schedule.Next, id))
+	if count := s.GetScheduleCount(schedule.Sender, false); count > 0 {
+		s.setScheduleCount(schedule.Sender, count-1)
+	}
+}
+
+// GetScheduleCount returns the number of active schedules of the sender.
+func (s Store) GetScheduleCount(sender crypto.Address, committed bool) uint64 {
+	b := s.get(getScheduleCountKey(sender), committed)
+	if len(b) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (s Store) setScheduleCount(sender crypto.Address, count uint64) {
+	if count == 0 {
+		s.remove(getScheduleCountKey(sender))
+		return
+	}
+	s.set(getScheduleCountKey(sender), convUint64(count))
+}
+
+// chargeSchedule takes the amount of a transfer from the sender, if the sender
+// can afford it, and returns the reason why it cannot otherwise.
+func (s Store) chargeSchedule(schedule *types.Schedule, height int64) string {
+	if schedule.Escrow != nil {
+		if schedule.Escrow.LessThan(&schedule.Amount) {
+			return "not enough escrow"
+		}
+		schedule.Escrow.Sub(&schedule.Amount)
+		return ""
+	}
+	balance := s.GetUDCBalance(schedule.UDC, schedule.Sender, false)
+	required := s.GetUDCLockSchedule(schedule.UDC, schedule.Sender,
+		false).Locked(height)
+	required.Add(&schedule.Amount)
+	if balance.LessThan(required) {
+		return "not enough balance"
+	}
+	balance.Sub(&schedule.Amount)
+	s.SetUDCBalance(schedule.UDC, schedule.Sender, balance)
+	return ""
+}
+
+// ExecuteSchedules makes the transfers due until *height*. A transfer is
+// skipped when the sender cannot afford it or either party is frozen, but it
+// counts as made anyway. What is left in escrow is returned to the sender even
+// when frozen.
+func (s Store) ExecuteSchedules(height int64, committed bool) []abci.Event {
+	events := []abci.Event{}
+
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return events
+	}
+
+	var ids []uint64
+	end := getScheduleDueKey(height, ^uint64(0))
+	imt.IterateRangeInclusive(prefixScheduleDue, end, true,
+		func(key []byte, value []byte, version int64) bool {
+			if !bytes.HasPrefix(key, prefixScheduleDue) {
+				return true
+			}
+			ids = append(ids, binary.BigEndian.Uint64(key[len(key)-8:]))
+			return false
+		},
+	)
+
+	for _, id := range ids {
+		schedule := s.GetSchedule(id, false)
+		if schedule == nil {
+			continue
+		}
+
+		reason := ""
+		if s.isUDCFrozenFor(schedule.UDC, schedule.Sender) ||
+			s.isUDCFrozenFor(schedule.UDC, schedule.Recipient) {
+			reason = "frozen"
+		}
+		if len(reason) == 0 {
+			reason = s.chargeSchedule(schedule, height)
+		}
+
+		idJson, _ := json.Marshal(id)
+		if len(reason) == 0 {
+			s.addCoin(schedule.UDC, schedule.Recipient, &schedule.Amount)
+			recipientJson, _ := json.Marshal(schedule.Recipient)
+			amountJson, _ := json.Marshal(schedule.Amount)
+			events = append(events, abci.Event{
+				Type: "schedule_transfer",
+				Attributes: []kv.Pair{
+					{Key: []byte("id"), Value: idJson},
+					{Key: []byte("recipient"), Value: recipientJson},
+					{Key: []byte("amount"), Value: amountJson},
+				},
+			})
+		} else {
+			reasonJson, _ := json.Marshal(reason)
+			events = append(events, abci.Event{
+				Type: "schedule_skip",
+				Attributes: []kv.Pair{
+					{Key: []byte("id"), Value: idJson},
+					{Key: []byte("reason"), Value: reasonJson},
+				},
+			})
+		}
+
+		schedule.Count--
+		if schedule.Count == 0 || schedule.Interval <= 0 ||
+			schedule.Next > math.MaxInt64-schedule.Interval {
+			// return what is left in escrow after skipped transfers
+			if schedule.Escrow != nil && schedule.Escrow.GreaterThan(types.Zero) {
+				s.addCoin(schedule.UDC, schedule.Sender, schedule.Escrow)
+			}
+			s.DeleteSchedule(id)
+			continue
+		}
+		schedule.Next += schedule.Interval
+		s.SetSchedule(id, schedule)
+	}
+
+	return events
+}
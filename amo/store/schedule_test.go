@@ -0,0 +1,97 @@
+package store
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	tmdb "github.com/tendermint/tm-db"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+func TestExecuteSchedules(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	alice := makeAccAddr("alice")
+	bob := makeAccAddr("bob")
+	carol := makeAccAddr("carol")
+	s.SetBalance(alice, new(types.Currency).Set(150))
+	s.SetUDC(123, &types.UDC{Total: *new(types.Currency).Set(1000)})
+
+	// charged at each transfer
+	charged := s.NextScheduleID()
+	s.SetSchedule(charged, &types.Schedule{
+		Sender:    alice,
+		Recipient: bob,
+		Amount:    *new(types.Currency).Set(100),
+		Interval:  10,
+		Count:     3,
+		Next:      10,
+	})
+	// funded by escrow
+	escrowed := s.NextScheduleID()
+	s.SetSchedule(escrowed, &types.Schedule{
+		Sender:    alice,
+		Recipient: carol,
+		UDC:       123,
+		Amount:    *new(types.Currency).Set(10),
+		Interval:  5,
+		Count:     2,
+		Next:      15,
+		Escrow:    new(types.Currency).Set(20),
+	})
+
+	assert.Equal(t, 0, len(s.ExecuteSchedules(9, false)))
+
+	events := s.ExecuteSchedules(10, false)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "schedule_transfer", events[0].Type)
+	assert.Equal(t, new(types.Currency).Set(100), s.GetBalance(bob, false))
+	assert.Equal(t, int64(20), s.GetSchedule(charged, false).Next)
+	assert.Equal(t, uint64(2), s.GetSchedule(charged, false).Count)
+
+	// frozen recipient
+	s.SetUDCFrozen(123, carol, true)
+	events = s.ExecuteSchedules(15, false)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "schedule_skip", events[0].Type)
+	s.SetUDCFrozen(123, carol, false)
+
+	// not enough balance for the charged one
+	events = s.ExecuteSchedules(20, false)
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, "schedule_skip", events[0].Type)
+	assert.Equal(t, "schedule_transfer", events[1].Type)
+	assert.Equal(t, new(types.Currency).Set(10), s.GetUDCBalance(123, carol, false))
+	// escrow left after the skipped transfer is returned
+	assert.Nil(t, s.GetSchedule(escrowed, false))
+	assert.Equal(t, new(types.Currency).Set(10), s.GetUDCBalance(123, alice, false))
+
+	s.SetBalance(alice, new(types.Currency).Set(100))
+	events = s.ExecuteSchedules(30, false)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "schedule_transfer", events[0].Type)
+	assert.Nil(t, s.GetSchedule(charged, false))
+	assert.Equal(t, new(types.Currency).Set(200), s.GetBalance(bob, false))
+	assert.Equal(t, 0, len(s.ExecuteSchedules(40, false)))
+	assert.Equal(t, uint64(0), s.GetScheduleCount(alice, false))
+
+	// ends instead of overflowing the next height
+	last := s.NextScheduleID()
+	s.SetSchedule(last, &types.Schedule{
+		Sender:    alice,
+		Recipient: bob,
+		Amount:    *new(types.Currency).Set(1),
+		Interval:  math.MaxInt64,
+		Count:     2,
+		Next:      50,
+		Escrow:    new(types.Currency).Set(2),
+	})
+	assert.Equal(t, uint64(1), s.GetScheduleCount(alice, false))
+	events = s.ExecuteSchedules(50, false)
+	assert.Equal(t, 1, len(events))
+	assert.Nil(t, s.GetSchedule(last, false))
+	assert.Equal(t, uint64(0), s.GetScheduleCount(alice, false))
+}
@@ -1,13 +1,21 @@
 package store
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
+	"math/big"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/libs/kv"
 
 	"github.com/amolabs/amoabci/amo/types"
 )
 
 var (
-	prefixStorage = []byte("storage:")
+	prefixStorage     = []byte("storage:")
+	prefixStorageBond = []byte("storagebond:")
 )
 
 func getStorageKey(id uint32) []byte {
@@ -36,3 +44,298 @@ func (s Store) GetStorage(id uint32, committed bool) *types.Storage {
 	}
 	return &sto
 }
+
+// Storage bond store
+func getLockedStorageBondKey(id uint32, height int64) []byte {
+	hb := make([]byte, 8)
+	binary.BigEndian.PutUint64(hb, uint64(height))
+	key := append([]byte{}, prefixStorageBond...)
+	key = append(key, ConvIDFromUint(id)...)
+	key = append(key, hb...)
+	return key
+}
+
+func splitLockedStorageBondKey(key []byte) (uint32, int64) {
+	if len(key) != len(prefixStorageBond)+types.StorageIDLen+8 {
+		return 0, 0
+	}
+	id := binary.BigEndian.Uint32(key[len(prefixStorageBond):])
+	h := binary.BigEndian.Uint64(key[len(prefixStorageBond)+types.StorageIDLen:])
+	return id, int64(h)
+}
+
+// SetLockedStorageBond stores a bond of a closed storage locked at *height*.
+// The bond's height is decremented each time when LoosenStorageBonds is
+// called.
+func (s Store) SetLockedStorageBond(id uint32, bond *types.StorageBond, height int64) error {
+	key := getLockedStorageBondKey(id, height)
+	if bond.Amount.Sign() == 0 {
+		s.remove(key)
+		return nil
+	}
+	b, err := json.Marshal(bond)
+	if err != nil {
+		return err
+	}
+	s.set(key, b)
+	return nil
+}
+
+func (s Store) GetLockedStorageBond(id uint32, height int64, committed bool) *types.StorageBond {
+	b := s.get(getLockedStorageBondKey(id, height), committed)
+	if len(b) == 0 {
+		return nil
+	}
+	var bond types.StorageBond
+	err := json.Unmarshal(b, &bond)
+	if err != nil {
+		return nil
+	}
+	return &bond
+}
+
+func (s Store) GetLockedStorageBondsWithHeight(id uint32, committed bool) ([]*types.StorageBond, []int64) {
+	prefix := append([]byte{}, prefixStorageBond...)
+	prefix = append(prefix, ConvIDFromUint(id)...)
+
+	var (
+		bonds   []*types.StorageBond
+		heights []int64
+	)
+
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return nil, nil
+	}
+
+	imt.IterateRangeInclusive(prefix, nil, true, func(key []byte, value []byte, version int64) bool {
+		if !bytes.HasPrefix(key, prefix) {
+			return true
+		}
+
+		_, height := splitLockedStorageBondKey(key)
+		if height <= 0 {
+			return false // continue
+		}
+
+		bond := new(types.StorageBond)
+		err := json.Unmarshal(value, bond)
+		if err != nil {
+			return false // continue
+		}
+
+		bonds = append(bonds, bond)
+		heights = append(heights, height)
+
+		return false
+	})
+
+	return bonds, heights
+}
+
+func (s Store) LoosenStorageBonds(committed bool) []abci.Event {
+	events := []abci.Event{}
+
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return events
+	}
+
+	var (
+		keys   [][]byte
+		values [][]byte
+	)
+	imt.IterateRangeInclusive(prefixStorageBond, nil, true, func(key []byte, value []byte, version int64) bool {
+		if !bytes.HasPrefix(key, prefixStorageBond) {
+			return true
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+		return false
+	})
+
+	for i, key := range keys {
+		id, height := splitLockedStorageBondKey(key)
+		if height <= 0 {
+			// db corruption detected. but we can do nothing here. just skip.
+			continue
+		}
+
+		bond := new(types.StorageBond)
+		err := json.Unmarshal(values[i], bond)
+		if err != nil {
+			continue
+		}
+
+		s.remove(key)
+		if height > 1 {
+			s.set(getLockedStorageBondKey(id, height-1), values[i])
+			continue
+		}
+
+		balance := s.GetBalance(bond.Owner, false)
+		balance.Add(&bond.Amount)
+		s.SetBalance(bond.Owner, balance)
+
+		idJson, _ := json.Marshal(id)
+		addressJson, _ := json.Marshal(bond.Owner)
+		amountJson, _ := json.Marshal(bond.Amount)
+		events = append(events, abci.Event{
+			Type: "bond_unlock",
+			Attributes: []kv.Pair{
+				{Key: []byte("storage"), Value: idJson},
+				{Key: []byte("address"), Value: addressJson},
+				{Key: []byte("amount"), Value: amountJson},
+			},
+		})
+	}
+
+	return events
+}
+
+// SlashStorage takes *ratio* of the whole bond of a storage, including the
+// locked bonds left after closing the storage, and distributes it equally to
+// the usage holders of the parcels in the storage. The remainder which cannot
+// be divided equally, or the whole amount when there is no usage holder, is
+// burnt and reported as *burnt* in the storage_slash event.
+func (s Store) SlashStorage(id uint32, ratio float64, committed bool) []abci.Event {
+	events := []abci.Event{}
+
+	sto := s.GetStorage(id, committed)
+	if sto == nil {
+		return events
+	}
+	bonds, heights := s.GetLockedStorageBondsWithHeight(id, committed)
+
+	total := new(types.Currency).Set(0)
+	total.Add(&sto.Bond)
+	for _, bond := range bonds {
+		total.Add(&bond.Amount)
+	}
+
+	// amount = total * ratio
+	tf := new(big.Float).SetInt(&total.Int)
+	rf := new(big.Float).SetFloat64(ratio)
+	af := tf.Mul(tf, rf)
+	amount := new(types.Currency)
+	af.Int(&amount.Int)
+	if amount.GreaterThan(total) {
+		amount = total
+	}
+	if !amount.GreaterThan(types.Zero) {
+		return events
+	}
+
+	// slash active bond first, then locked bonds closer to be unlocked
+	left := new(types.Currency).Set(0)
+	left.Add(amount)
+	if left.LessThan(&sto.Bond) {
+		sto.Bond.Sub(left)
+		left.Set(0)
+	} else {
+		left.Sub(&sto.Bond)
+		sto.Bond.Set(0)
+	}
+	s.SetStorage(id, sto)
+	for i, bond := range bonds {
+		if left.Equals(types.Zero) {
+			break
+		}
+		if left.LessThan(&bond.Amount) {
+			bond.Amount.Sub(left)
+			left.Set(0)
+		} else {
+			left.Sub(&bond.Amount)
+			bond.Amount.Set(0)
+		}
+		s.SetLockedStorageBond(id, bond, heights[i])
+	}
+
+	// share = amount / len(usages)
+	_, usages := s.GetUsagesByStorage(id, committed)
+	share := new(types.Currency)
+	burnt := new(types.Currency).Set(0)
+	burnt.Add(amount)
+	if len(usages) > 0 {
+		share.Div(&amount.Int, big.NewInt(int64(len(usages))))
+		paid := new(big.Int).Mul(&share.Int, big.NewInt(int64(len(usages))))
+		burnt.Int.Sub(&burnt.Int, paid)
+	}
+
+	idJson, _ := json.Marshal(id)
+	amountJson, _ := json.Marshal(amount)
+	burntJson, _ := json.Marshal(burnt)
+	events = append(events, abci.Event{
+		Type: "storage_slash",
+		Attributes: []kv.Pair{
+			{Key: []byte("storage"), Value: idJson},
+			{Key: []byte("amount"), Value: amountJson},
+			{Key: []byte("burnt"), Value: burntJson},
+		},
+	})
+
+	if share.Equals(types.Zero) {
+		return events
+	}
+	for _, usage := range usages {
+		balance := s.GetBalance(usage.Recipient, committed)
+		balance.Add(share)
+		s.SetBalance(usage.Recipient, balance)
+		// event
+		addressJson, _ := json.Marshal(usage.Recipient)
+		amountJson, _ := json.Marshal(share)
+		events = append(events, abci.Event{
+			Type: "slash_compensation",
+			Attributes: []kv.Pair{
+				{Key: []byte("address"), Value: addressJson},
+				{Key: []byte("amount"), Value: amountJson},
+			},
+		})
+	}
+
+	return events
+}
+
+// GetUsagesByStorage returns usages of all parcels hosted by a storage along
+// with their parcel IDs.
+func (s Store) GetUsagesByStorage(id uint32, committed bool) ([][]byte, []*types.UsageEx) {
+	prefix := append([]byte{}, prefixUsage...)
+	prefix = append(prefix, ConvIDFromUint(id)...)
+
+	var (
+		parcelIDs [][]byte
+		usages    []*types.UsageEx
+	)
+
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return nil, nil
+	}
+
+	imt.IterateRangeInclusive(prefix, nil, true, func(key []byte, value []byte, version int64) bool {
+		if !bytes.HasPrefix(key, prefix) {
+			return true
+		}
+		// parcelBuyerKey has no value, while recipientParcelKey has a
+		// marshaled usage as its value.
+		if len(value) != 0 || len(key) < len(prefix)+crypto.AddressSize+1 {
+			return false // continue
+		}
+
+		parcelID, recipient := splitParcelBuyerKey(prefixUsage, key)
+		usage := s.GetUsage(recipient, parcelID, committed)
+		if usage == nil {
+			return false // continue
+		}
+
+		parcelIDs = append(parcelIDs, parcelID)
+		usages = append(usages, &types.UsageEx{
+			Usage:     usage,
+			Recipient: recipient,
+		})
+
+		return false
+	})
+
+	return parcelIDs, usages
+}
@@ -1,9 +1,12 @@
 package store
 
 import (
+	"encoding/binary"
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/crypto"
 	tmdb "github.com/tendermint/tm-db"
 
 	"github.com/amolabs/amoabci/amo/types"
@@ -39,3 +42,174 @@ func TestStorageSetGet(t *testing.T) {
 	assert.NotNil(t, sto)
 	assert.Equal(t, mysto, sto)
 }
+
+func TestStorageEncoding(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	// storage as it was before protocol v6
+	legacy, err := json.Marshal(struct {
+		Owner           crypto.Address `json:"owner"`
+		Url             string         `json:"url"`
+		RegistrationFee types.Currency `json:"registration_fee"`
+		HostingFee      types.Currency `json:"hosting_fee"`
+		Active          bool           `json:"active"`
+	}{
+		Owner:           makeAccAddr("provider"),
+		Url:             "http://need_to_check_url_format",
+		RegistrationFee: *new(types.Currency).SetAMO(1),
+		HostingFee:      *new(types.Currency).SetAMO(1),
+		Active:          true,
+	})
+	assert.NoError(t, err)
+
+	sto := &types.Storage{
+		Owner:           makeAccAddr("provider"),
+		Url:             "http://need_to_check_url_format",
+		RegistrationFee: *new(types.Currency).SetAMO(1),
+		HostingFee:      *new(types.Currency).SetAMO(1),
+		Active:          true,
+	}
+	assert.NoError(t, s.SetStorage(123, sto))
+	assert.Equal(t, legacy, s.get(getStorageKey(123), false))
+
+	// bonded storage
+	sto.Bond.Set(600)
+	assert.NoError(t, s.SetStorage(123, sto))
+	assert.Contains(t, string(s.get(getStorageKey(123), false)),
+		`"bond":"600"`)
+	assert.Equal(t, sto, s.GetStorage(123, false))
+}
+
+func TestStorageSlash(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	storageID := uint32(123)
+	sto := &types.Storage{
+		Owner:  makeAccAddr("provider"),
+		Url:    "http://need_to_check_url_format",
+		Bond:   *new(types.Currency).Set(600),
+		Active: true,
+	}
+	assert.NoError(t, s.SetStorage(storageID, sto))
+	assert.NoError(t, s.SetLockedStorageBond(storageID, &types.StorageBond{
+		Owner:  makeAccAddr("provider"),
+		Amount: *new(types.Currency).Set(400),
+	}, 10))
+
+	bonds, heights := s.GetLockedStorageBondsWithHeight(storageID, false)
+	assert.Equal(t, 1, len(bonds))
+	assert.Equal(t, []int64{10}, heights)
+
+	// parcels in the storage and usages of them
+	parcelID := make([]byte, 4)
+	binary.BigEndian.PutUint32(parcelID, storageID)
+	parcelID = append(parcelID, []byte("parcel")...)
+	s.SetUsage(makeAccAddr("buyer1"), parcelID, &types.Usage{})
+	s.SetUsage(makeAccAddr("buyer2"), parcelID, &types.Usage{})
+	otherID := make([]byte, 4)
+	binary.BigEndian.PutUint32(otherID, storageID+1)
+	otherID = append(otherID, []byte("parcel")...)
+	s.SetUsage(makeAccAddr("buyer3"), otherID, &types.Usage{})
+
+	parcelIDs, usages := s.GetUsagesByStorage(storageID, false)
+	assert.Equal(t, 2, len(parcelIDs))
+	assert.Equal(t, 2, len(usages))
+
+	// slash 80% of 1000
+	evs := s.SlashStorage(storageID, 0.8, false)
+	assert.Equal(t, 3, len(evs))
+
+	sto = s.GetStorage(storageID, false)
+	assert.Equal(t, types.Zero, &sto.Bond)
+	bond := s.GetLockedStorageBond(storageID, 10, false)
+	assert.NotNil(t, bond)
+	assert.Equal(t, new(types.Currency).Set(200), &bond.Amount)
+
+	assert.Equal(t, new(types.Currency).Set(400),
+		s.GetBalance(makeAccAddr("buyer1"), false))
+	assert.Equal(t, new(types.Currency).Set(400),
+		s.GetBalance(makeAccAddr("buyer2"), false))
+	assert.Equal(t, types.Zero, s.GetBalance(makeAccAddr("buyer3"), false))
+	assert.Equal(t, []byte(`"0"`), evs[0].Attributes[2].Value)
+
+	// burnt as a whole without any usage holder
+	assert.NoError(t, s.SetStorage(storageID+2, &types.Storage{
+		Owner: makeAccAddr("provider"),
+		Bond:  *new(types.Currency).Set(100),
+	}))
+	evs = s.SlashStorage(storageID+2, 0.5, false)
+	assert.Equal(t, 1, len(evs))
+	assert.Equal(t, []byte("burnt"), evs[0].Attributes[2].Key)
+	assert.Equal(t, []byte(`"50"`), evs[0].Attributes[2].Value)
+}
+
+func TestChallengeExpire(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	storageID := uint32(123)
+	sto := &types.Storage{
+		Owner:  makeAccAddr("provider"),
+		Url:    "http://need_to_check_url_format",
+		Bond:   *new(types.Currency).Set(1000),
+		Active: true,
+	}
+	assert.NoError(t, s.SetStorage(storageID, sto))
+
+	parcelID := make([]byte, 4)
+	binary.BigEndian.PutUint32(parcelID, storageID)
+	parcelID = append(parcelID, []byte("parcel")...)
+	s.SetUsage(makeAccAddr("buyer"), parcelID, &types.Usage{})
+
+	challenge := &types.Challenge{
+		Challenger:  makeAccAddr("challenger"),
+		ContentRoot: []byte("content root"),
+		ChunkCount:  5,
+		Index:       3,
+		Start:       10,
+		Deadline:    20,
+		Deposit:     *new(types.Currency).Set(10),
+	}
+	assert.NoError(t, s.SetChallenge(parcelID, challenge))
+	assert.Equal(t, challenge, s.GetChallenge(parcelID, false))
+	assert.Equal(t, 1, len(s.GetChallenges(false)))
+
+	// not expired yet
+	evs := s.ExpireChallenges(19, 0.1, false)
+	assert.Equal(t, 0, len(evs))
+	assert.NotNil(t, s.GetChallenge(parcelID, false))
+	assert.Equal(t, 0, len(s.GetMissedChallenges(storageID, false)))
+
+	// expired: challenge_miss, storage_slash, slash_compensation
+	evs = s.ExpireChallenges(20, 0.1, false)
+	assert.Equal(t, 3, len(evs))
+	assert.Nil(t, s.GetChallenge(parcelID, false))
+	missed := s.GetMissedChallenges(storageID, false)
+	assert.Equal(t, 1, len(missed))
+	assert.Equal(t, challenge, missed[0].Challenge)
+	assert.Equal(t, parcelID, []byte(missed[0].Parcel))
+	assert.Equal(t, 0, len(s.GetMissedChallenges(storageID+1, false)))
+
+	sto = s.GetStorage(storageID, false)
+	assert.Equal(t, new(types.Currency).Set(900), &sto.Bond)
+	assert.Equal(t, new(types.Currency).Set(100),
+		s.GetBalance(makeAccAddr("buyer"), false))
+	// deposit returned to the challenger
+	assert.Equal(t, new(types.Currency).Set(10),
+		s.GetBalance(makeAccAddr("challenger"), false))
+	assert.Equal(t, int64(20), s.GetLastChallengeDeadline(parcelID, false))
+
+	// expired by the deadline of the latest one
+	other := append(append([]byte{}, parcelID...), 'x')
+	challenge = &types.Challenge{Start: 25, Deadline: 30}
+	assert.NoError(t, s.SetChallenge(other, challenge))
+	challenge.Deadline = 40
+	assert.NoError(t, s.SetChallenge(other, challenge))
+	assert.Equal(t, 0, len(s.ExpireChallenges(39, 0, false)))
+	assert.Equal(t, 1, len(s.ExpireChallenges(40, 0, false)))
+	assert.Nil(t, s.GetChallenge(other, false))
+}
@@ -42,6 +42,10 @@ var (
 	prefixIndexDelegator = []byte("delegator")
 	prefixIndexValidator = []byte("validator")
 	prefixIndexEffStake  = []byte("effstake")
+	prefixIndexAgency    = []byte("agency")
+	prefixIndexUDCHolder = []byte("udcholder")
+	prefixIndexUDCLocker = []byte("udclocker")
+	prefixIndexUDCCount  = []byte("udccount")
 
 	prefixMissRun = []byte("miss_run")
 )
@@ -69,6 +73,22 @@ type Store struct {
 	// key: effective stake (32 bytes) || stake holder address
 	// value: nil
 	indexEffStake tmdb.DB
+	// search index for requests and usages by agency:
+	// key: agency address || recipient address || parcel id
+	// value: nil
+	indexAgency tmdb.DB
+	// search index for holders of UDCs:
+	// key: udc id || holder address
+	// value: nil
+	indexUDCHolder tmdb.DB
+	// search index for holders of UDCs having locks:
+	// key: udc id || holder address
+	// value: nil
+	indexUDCLocker tmdb.DB
+	// number of holders of UDCs:
+	// key: udc id
+	// value: number of holders (8 bytes)
+	indexUDCCount tmdb.DB
 
 	// search index for block-first delivered txs
 	// key: block height
@@ -111,6 +131,10 @@ func NewStore(logger log.Logger, checkpoint_interval int64, merkleDB, indexDB tm
 		indexDelegator: tmdb.NewPrefixDB(indexDB, prefixIndexDelegator),
 		indexValidator: tmdb.NewPrefixDB(indexDB, prefixIndexValidator),
 		indexEffStake:  tmdb.NewPrefixDB(indexDB, prefixIndexEffStake),
+		indexAgency:    tmdb.NewPrefixDB(indexDB, prefixIndexAgency),
+		indexUDCHolder: tmdb.NewPrefixDB(indexDB, prefixIndexUDCHolder),
+		indexUDCLocker: tmdb.NewPrefixDB(indexDB, prefixIndexUDCLocker),
+		indexUDCCount:  tmdb.NewPrefixDB(indexDB, prefixIndexUDCCount),
 		indexBlockTx:   tmdb.NewPrefixDB(indexDB, prefixIndexBlockTx),
 		indexTxBlock:   tmdb.NewPrefixDB(indexDB, prefixIndexTxBlock),
 
@@ -1210,6 +1234,15 @@ func (s *Store) ProcessDraftVotes(
 				{Key: []byte("config"), Value: b},
 			},
 		})
+
+		if draft.StorageSlash != nil {
+			evs := s.SlashStorage(
+				draft.StorageSlash.Storage,
+				draft.StorageSlash.Ratio,
+				committed,
+			)
+			events = append(events, evs...)
+		}
 	}
 	return events
 }
@@ -1328,6 +1361,46 @@ func splitParcelBuyerKey(prefix, key []byte) (parcelID []byte, recipient crypto.
 	return
 }
 
+// iterateRecipientKeys visits at most num entries stored under recipient-first
+// keys, starting from the parcel id given as from, and returns the parcel id
+// of the next entry to visit, if any.
+func (s *Store) iterateRecipientKeys(prefix []byte, recipient crypto.Address,
+	from []byte, num int, committed bool,
+	fn func(parcelID, value []byte)) (next []byte) {
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return nil
+	}
+
+	prefixKey := append([]byte{}, prefix...)
+	prefixKey = append(prefixKey, recipient...)
+	prefixKey = append(prefixKey, ':')
+	start := append(append([]byte{}, prefixKey...), from...)
+
+	count := 0
+	imt.IterateRangeInclusive(start, nil, true,
+		func(key []byte, value []byte, version int64) bool {
+			if !bytes.HasPrefix(key, prefixKey) {
+				return true
+			}
+			// parcelBuyerKey may share the prefix, but has no value
+			if len(value) == 0 {
+				return false
+			}
+			parcelID := key[len(prefixKey):]
+			if count >= num {
+				next = append([]byte{}, parcelID...)
+				return true
+			}
+			fn(parcelID, value)
+			count++
+			return false
+		},
+	)
+
+	return next
+}
+
 func (s *Store) SetRequest(recipient crypto.Address, parcelID []byte, value *types.Request) error {
 	b, err := json.Marshal(value)
 	if err != nil {
@@ -1340,6 +1413,8 @@ func (s *Store) SetRequest(recipient crypto.Address, parcelID []byte, value *typ
 	s.set(recipientParcelKey, b)
 	s.set(parcelBuyerKey, []byte{})
 
+	s.updateAgencyIndex(value.Agency, recipient, parcelID)
+
 	return nil
 }
 
@@ -1394,10 +1469,41 @@ func (s *Store) GetRequests(parcelID []byte, committed bool) []*types.RequestEx
 }
 
 func (s *Store) DeleteRequest(recipient crypto.Address, parcelID []byte) {
+	request := s.GetRequest(recipient, parcelID, false)
+
 	recipientParcelKey, parcelBuyerKey := makeRequestKey(recipient, parcelID)
 
 	s.remove(recipientParcelKey)
 	s.remove(parcelBuyerKey)
+
+	if request != nil {
+		s.updateAgencyIndex(request.Agency, recipient, parcelID)
+	}
+}
+
+// GetRequestsByRecipient returns at most num requests made for the recipient,
+// starting from the parcel id given as from, along with the parcel id to start
+// the next page from.
+func (s *Store) GetRequestsByRecipient(recipient crypto.Address,
+	from []byte, num int, committed bool) ([]*types.RequestEx, []byte) {
+	requests := []*types.RequestEx{}
+
+	next := s.iterateRecipientKeys(prefixRequest, recipient, from, num,
+		committed, func(parcelID, value []byte) {
+			var request types.Request
+			err := json.Unmarshal(value, &request)
+			if err != nil {
+				return
+			}
+			requests = append(requests, &types.RequestEx{
+				Request:   &request,
+				Recipient: recipient,
+				Target:    append([]byte{}, parcelID...),
+			})
+		},
+	)
+
+	return requests, next
 }
 
 // Usage store
@@ -1419,6 +1525,8 @@ func (s *Store) SetUsage(recipient crypto.Address, parcelID []byte, value *types
 	s.set(recipientParcelKey, b)
 	s.set(parcelBuyerKey, []byte{})
 
+	s.updateAgencyIndex(value.Agency, recipient, parcelID)
+
 	return nil
 }
 
@@ -1468,10 +1576,41 @@ func (s *Store) GetUsages(parcelID []byte, committed bool) []*types.UsageEx {
 }
 
 func (s *Store) DeleteUsage(recipient crypto.Address, parcelID []byte) {
+	usage := s.GetUsage(recipient, parcelID, false)
+
 	recipientParcelKey, parcelBuyerKey := makeUsageKey(recipient, parcelID)
 
 	s.remove(recipientParcelKey)
 	s.remove(parcelBuyerKey)
+
+	if usage != nil {
+		s.updateAgencyIndex(usage.Agency, recipient, parcelID)
+	}
+}
+
+// GetUsagesByRecipient returns at most num usages granted to the recipient,
+// starting from the parcel id given as from, along with the parcel id to start
+// the next page from.
+func (s *Store) GetUsagesByRecipient(recipient crypto.Address,
+	from []byte, num int, committed bool) ([]*types.UsageEx, []byte) {
+	usages := []*types.UsageEx{}
+
+	next := s.iterateRecipientKeys(prefixUsage, recipient, from, num,
+		committed, func(parcelID, value []byte) {
+			var usage types.Usage
+			err := json.Unmarshal(value, &usage)
+			if err != nil {
+				return
+			}
+			usages = append(usages, &types.UsageEx{
+				Usage:     &usage,
+				Recipient: recipient,
+				Target:    append([]byte{}, parcelID...),
+			})
+		},
+	)
+
+	return usages, next
 }
 
 func (s *Store) GetValidators(max uint64, committed bool) abci.ValidatorUpdates {
@@ -1524,6 +1663,10 @@ func (s *Store) RebuildIndex() {
 	purgeDB(s.indexDelegator)
 	purgeDB(s.indexValidator)
 	purgeDB(s.indexEffStake)
+	purgeDB(s.indexAgency)
+	purgeDB(s.indexUDCHolder)
+	purgeDB(s.indexUDCLocker)
+	purgeDB(s.indexUDCCount)
 
 	var start, end []byte
 
@@ -1574,6 +1717,87 @@ func (s *Store) RebuildIndex() {
 	})
 	bVal.Write()
 	bEff.Write()
+
+	bAgency := s.indexAgency.NewBatch()
+	defer bAgency.Close()
+	for _, prefix := range [][]byte{prefixRequest, prefixUsage} {
+		prefixLen = len(prefix)
+		start = prefix
+		end = make([]byte, prefixLen)
+		copy(end, start)
+		end[prefixLen-1] = ';'
+		s.merkleTree.IterateRange(start, end, true, func(k, v []byte) bool {
+			// skip parcel-buyer keys used as index
+			if len(v) == 0 || len(k) <= prefixLen+crypto.AddressSize+1 {
+				return false
+			}
+			var entry struct {
+				Agency crypto.Address `json:"agency"`
+			}
+			err := json.Unmarshal(v, &entry)
+			if err != nil || len(entry.Agency) == 0 {
+				return false
+			}
+			recipient := k[prefixLen : prefixLen+crypto.AddressSize]
+			parcelID := k[prefixLen+crypto.AddressSize+1:]
+			bAgency.Set(makeAgencyIndexKey(entry.Agency, recipient, parcelID), nil)
+			return false
+		})
+	}
+	bAgency.Write()
+
+	bHolder := s.indexUDCHolder.NewBatch()
+	defer bHolder.Close()
+	bLocker := s.indexUDCLocker.NewBatch()
+	defer bLocker.Close()
+	for _, prefix := range [][]byte{prefixBalance, prefixUDCLock} {
+		prefixLen = len(prefix)
+		start = prefix
+		end = make([]byte, prefixLen)
+		copy(end, start)
+		end[prefixLen-1] = ';'
+		s.merkleTree.IterateRange(start, end, true, func(k, v []byte) bool {
+			// skip AMO balances
+			if len(k) != prefixLen+4+1+crypto.AddressSize {
+				return false
+			}
+			var amount types.Currency
+			if bytes.Equal(prefix, prefixUDCLock) {
+				var lock types.UDCLock
+				if json.Unmarshal(v, &lock) != nil {
+					return false
+				}
+				amount = lock.Amount
+			} else if json.Unmarshal(v, &amount) != nil {
+				return false
+			}
+			if !amount.GreaterThan(new(types.Currency)) {
+				return false
+			}
+			udc := k[prefixLen : prefixLen+4]
+			holder := k[prefixLen+4+1:]
+			key := append(append([]byte{}, udc...), holder...)
+			bHolder.Set(key, nil)
+			if bytes.Equal(prefix, prefixUDCLock) {
+				bLocker.Set(key, nil)
+			}
+			return false
+		})
+	}
+	bHolder.Write()
+	bLocker.Write()
+
+	counts := make(map[uint32]uint64)
+	itr, err := s.indexUDCHolder.Iterator(nil, nil)
+	if err == nil {
+		for ; itr.Valid(); itr.Next() {
+			counts[binary.BigEndian.Uint32(itr.Key()[:4])]++
+		}
+		itr.Close()
+	}
+	for udc, count := range counts {
+		s.setUDCHolderCount(udc, count)
+	}
 }
 
 func (s *Store) Close() {
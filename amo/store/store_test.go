@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/crypto/ed25519"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
 	tmos "github.com/tendermint/tendermint/libs/os"
 	tmrand "github.com/tendermint/tendermint/libs/rand"
 	tmdb "github.com/tendermint/tm-db"
@@ -174,6 +175,113 @@ func TestUsage(t *testing.T) {
 	assert.Equal(t, usageInput.Custody, (*usageOutput).Custody)
 	t.Log(usageInput)
 	t.Log(*usageOutput)
+
+	// usage as it was before protocol v6
+	legacy, err := json.Marshal(struct {
+		Custody tmbytes.HexBytes `json:"custody"`
+		Extra   types.Extra      `json:"extra,omitempty"`
+	}{
+		Custody: usageInput.Custody,
+		Extra:   usageInput.Extra,
+	})
+	assert.NoError(t, err)
+	key, _ := makeUsageKey(testAddr, parcelID)
+	assert.Equal(t, legacy, s.get(key, false))
+}
+
+func TestRecipientEntries(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	recipient := makeAccAddr("recipient")
+	other := makeAccAddr("other")
+
+	for i := byte(1); i <= 5; i++ {
+		parcelID := []byte{0x00, 0x00, 0x00, 0x01, i}
+		s.SetRequest(recipient, parcelID, &types.Request{
+			Payment: *new(types.Currency).Set(100),
+		})
+		s.SetUsage(other, parcelID, &types.Usage{})
+	}
+	// parcel id beginning with the recipient address
+	tricky := append(append([]byte{}, recipient...), ':')
+	s.SetRequest(other, tricky, &types.Request{
+		Payment: *new(types.Currency).Set(100),
+	})
+	s.Save()
+
+	requests, next := s.GetRequestsByRecipient(recipient, nil, 2, true)
+	assert.Equal(t, 2, len(requests))
+	assert.Equal(t, []byte{0x00, 0x00, 0x00, 0x01, 0x01},
+		[]byte(requests[0].Target))
+	assert.Equal(t, recipient, requests[0].Recipient)
+	assert.Equal(t, []byte{0x00, 0x00, 0x00, 0x01, 0x03}, next)
+
+	requests, next = s.GetRequestsByRecipient(recipient, next, 2, true)
+	assert.Equal(t, 2, len(requests))
+	assert.Equal(t, []byte{0x00, 0x00, 0x00, 0x01, 0x03},
+		[]byte(requests[0].Target))
+	assert.Equal(t, []byte{0x00, 0x00, 0x00, 0x01, 0x05}, next)
+
+	requests, next = s.GetRequestsByRecipient(recipient, next, 2, true)
+	assert.Equal(t, 1, len(requests))
+	assert.Nil(t, next)
+
+	usages, next := s.GetUsagesByRecipient(recipient, nil, 10, true)
+	assert.Equal(t, 0, len(usages))
+	assert.Nil(t, next)
+	usages, next = s.GetUsagesByRecipient(other, nil, 10, true)
+	assert.Equal(t, 5, len(usages))
+	assert.Nil(t, next)
+}
+
+func TestAgencyIndex(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	agency := makeAccAddr("agency")
+	parcelID1 := []byte("parcel1")
+	parcelID2 := []byte("parcel2")
+
+	s.SetRequest(makeAccAddr("recipient1"), parcelID1, &types.Request{
+		Payment: *new(types.Currency).Set(100),
+		Agency:  agency,
+	})
+	s.SetRequest(makeAccAddr("recipient2"), parcelID2, &types.Request{
+		Payment: *new(types.Currency).Set(100),
+		Agency:  agency,
+	})
+	s.SetRequest(makeAccAddr("recipient3"), parcelID2, &types.Request{
+		Payment: *new(types.Currency).Set(100),
+	})
+	requests, usages := s.GetAgencyEntries(agency, false)
+	assert.Equal(t, 2, len(requests))
+	assert.Equal(t, 0, len(usages))
+
+	// request turns into usage
+	s.DeleteRequest(makeAccAddr("recipient1"), parcelID1)
+	requests, usages = s.GetAgencyEntries(agency, false)
+	assert.Equal(t, 1, len(requests))
+	assert.Equal(t, makeAccAddr("recipient2"), requests[0].Recipient)
+	assert.Equal(t, parcelID2, []byte(requests[0].Target))
+	s.SetUsage(makeAccAddr("recipient1"), parcelID1, &types.Usage{
+		Agency: agency,
+	})
+	requests, usages = s.GetAgencyEntries(agency, false)
+	assert.Equal(t, 1, len(requests))
+	assert.Equal(t, 1, len(usages))
+	assert.Equal(t, parcelID1, []byte(usages[0].Target))
+
+	// rebuild
+	s.Save()
+	s.RebuildIndex()
+	requests, usages = s.GetAgencyEntries(agency, true)
+	assert.Equal(t, 1, len(requests))
+	assert.Equal(t, 1, len(usages))
+
+	s.DeleteUsage(makeAccAddr("recipient1"), parcelID1)
+	s.DeleteRequest(makeAccAddr("recipient2"), parcelID2)
+	requests, usages = s.GetAgencyEntries(agency, false)
+	assert.Equal(t, 0, len(requests))
+	assert.Equal(t, 0, len(usages))
 }
 
 func TestStake(t *testing.T) {
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 
@@ -12,6 +14,9 @@ import (
 var (
 	prefixUDC     = []byte("udc:")
 	prefixUDCLock = []byte("udclock:")
+	// udc:owner:spender
+	prefixUDCAllowance = []byte("udcallowance:")
+	prefixUDCFreeze    = []byte("udcfreeze:")
 )
 
 func getUDCKey(id uint32) []byte {
@@ -63,6 +68,7 @@ func (s Store) SetUDCBalance(udc uint32,
 	// pre-process for setting zero balance, just remove corresponding key
 	if s.has(balanceKey) && balance.Equals(zero) {
 		s.remove(balanceKey)
+		s.updateUDCHolderIndex(udc, addr)
 		return nil
 	}
 
@@ -72,6 +78,7 @@ func (s Store) SetUDCBalance(udc uint32,
 	}
 
 	s.set(balanceKey, b)
+	s.updateUDCHolderIndex(udc, addr)
 
 	return nil
 }
@@ -100,33 +107,102 @@ func getUDCLockKey(udc uint32, addr tm.Address) []byte {
 
 func (s Store) SetUDCLock(udc uint32,
 	addr tm.Address, amount *types.Currency) error {
+	return s.SetUDCLockSchedule(udc, addr, &types.UDCLock{Amount: *amount})
+}
+
+func (s Store) SetUDCLockSchedule(udc uint32,
+	addr tm.Address, lock *types.UDCLock) error {
 	zero := new(types.Currency).Set(0)
 	lockKey := getUDCLockKey(udc, addr)
 
-	if amount.LessThan(zero) {
+	if lock.Amount.LessThan(zero) {
 		return errors.New("negative amount")
 	}
 
 	// pre-process for setting zero amount, just remove corresponding key
-	if s.has(lockKey) && amount.Equals(zero) {
+	if s.has(lockKey) && lock.Amount.Equals(zero) {
 		s.remove(lockKey)
+		s.updateUDCHolderIndex(udc, addr)
 		return nil
 	}
 
-	b, err := json.Marshal(amount)
+	// a lock without a schedule is kept as a plain amount as before
+	var b []byte
+	var err error
+	if lock.Start == 0 && lock.Cliff == 0 && lock.End == 0 {
+		b, err = json.Marshal(&lock.Amount)
+	} else {
+		b, err = json.Marshal(lock)
+	}
 	if err != nil {
 		return err
 	}
 
 	s.set(lockKey, b)
+	s.updateUDCHolderIndex(udc, addr)
 
 	return nil
 }
 
+// GetUDCLock returns the amount of the lock regardless of its schedule.
 func (s Store) GetUDCLock(udc uint32,
 	addr tm.Address, committed bool) *types.Currency {
-	c := types.Currency{}
+	return &s.GetUDCLockSchedule(udc, addr, committed).Amount
+}
+
+func (s Store) GetUDCLockSchedule(udc uint32,
+	addr tm.Address, committed bool) *types.UDCLock {
+	lock := types.UDCLock{}
 	b := s.get(getUDCLockKey(udc, addr), committed)
+	if len(b) == 0 {
+		return &lock
+	}
+	err := json.Unmarshal(b, &lock)
+	if err != nil {
+		return &types.UDCLock{}
+	}
+	return &lock
+}
+
+// UDC Allowance store
+func getUDCAllowanceKey(udc uint32, owner, spender tm.Address) []byte {
+	key := append([]byte{}, prefixUDCAllowance...)
+	key = append(key, ConvIDFromUint(udc)...)
+	key = append(key, ':')
+	key = append(key, owner.Bytes()...)
+	key = append(key, spender.Bytes()...)
+	return key
+}
+
+func (s Store) SetUDCAllowance(udc uint32,
+	owner, spender tm.Address, amount *types.Currency) error {
+	zero := new(types.Currency).Set(0)
+	allowanceKey := getUDCAllowanceKey(udc, owner, spender)
+
+	if amount.LessThan(zero) {
+		return errors.New("negative amount")
+	}
+
+	// pre-process for setting zero amount, just remove corresponding key
+	if amount.Equals(zero) {
+		s.remove(allowanceKey)
+		return nil
+	}
+
+	b, err := json.Marshal(amount)
+	if err != nil {
+		return err
+	}
+
+	s.set(allowanceKey, b)
+
+	return nil
+}
+
+func (s Store) GetUDCAllowance(udc uint32,
+	owner, spender tm.Address, committed bool) *types.Currency {
+	c := types.Currency{}
+	b := s.get(getUDCAllowanceKey(udc, owner, spender), committed)
 	if len(b) == 0 {
 		return &c
 	}
@@ -136,3 +212,179 @@ func (s Store) GetUDCLock(udc uint32,
 	}
 	return &c
 }
+
+// UDC Freeze store
+func getUDCFreezeKey(udc uint32, addr tm.Address) []byte {
+	key := append([]byte{}, prefixUDCFreeze...)
+	key = append(key, ConvIDFromUint(udc)...)
+	key = append(key, ':')
+	key = append(key, addr.Bytes()...)
+	return key
+}
+
+func (s Store) SetUDCFrozen(udc uint32, addr tm.Address, frozen bool) {
+	if frozen {
+		s.set(getUDCFreezeKey(udc, addr), []byte{0x01})
+	} else {
+		s.remove(getUDCFreezeKey(udc, addr))
+	}
+}
+
+func (s Store) IsUDCFrozen(udc uint32, addr tm.Address, committed bool) bool {
+	return len(s.get(getUDCFreezeKey(udc, addr), committed)) > 0
+}
+
+// isUDCFrozenFor checks if the holder cannot be paid in the UDC, as the UDC is
+// frozen as a whole or for the holder. AMO is never frozen.
+func (s Store) isUDCFrozenFor(udc uint32, addr tm.Address) bool {
+	if udc == 0 {
+		return false
+	}
+	u := s.GetUDC(udc, false)
+	return (u != nil && u.Frozen) || s.IsUDCFrozen(udc, addr, false)
+}
+
+// UDC holder index
+func makeUDCHolderIndexKey(udc uint32, addr tm.Address) []byte {
+	return append(ConvIDFromUint(udc), addr.Bytes()...)
+}
+
+// updateUDCHolderIndex keeps an index entry for a holder as long as the holder
+// has either a balance or a lock of the UDC, along with the number of holders
+// and the entries of the holders having locks.
+func (s Store) updateUDCHolderIndex(udc uint32, addr tm.Address) {
+	if udc == 0 {
+		return
+	}
+	key := makeUDCHolderIndexKey(udc, addr)
+
+	zero := new(types.Currency).Set(0)
+	locked := s.GetUDCLock(udc, addr, false).GreaterThan(zero)
+	holding := locked || s.GetUDCBalance(udc, addr, false).GreaterThan(zero)
+
+	if locked {
+		s.indexUDCLocker.Set(key, nil)
+	} else {
+		s.indexUDCLocker.Delete(key)
+	}
+
+	indexed, err := s.indexUDCHolder.Has(key)
+	if err != nil {
+		s.logger.Error("Store", "updateUDCHolderIndex", err.Error())
+		return
+	}
+	switch {
+	case holding && !indexed:
+		s.indexUDCHolder.Set(key, nil)
+		s.setUDCHolderCount(udc, s.getUDCHolderCount(udc)+1)
+	case !holding && indexed:
+		s.indexUDCHolder.Delete(key)
+		if count := s.getUDCHolderCount(udc); count > 0 {
+			s.setUDCHolderCount(udc, count-1)
+		}
+	}
+}
+
+func (s Store) getUDCHolderCount(udc uint32) uint64 {
+	b, err := s.indexUDCCount.Get(ConvIDFromUint(udc))
+	if err != nil || len(b) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (s Store) setUDCHolderCount(udc uint32, count uint64) {
+	if count == 0 {
+		s.indexUDCCount.Delete(ConvIDFromUint(udc))
+		return
+	}
+	s.indexUDCCount.Set(ConvIDFromUint(udc), convUint64(count))
+}
+
+// iterateUDCHolders visits holders of the UDC in the order of address,
+// starting from the address given as from.
+func (s Store) iterateUDCHolders(udc uint32, from tm.Address,
+	fn func(addr tm.Address) bool) {
+	prefix := ConvIDFromUint(udc)
+	itr, err := s.indexUDCHolder.Iterator(append(prefix, from...), nil)
+	if err != nil {
+		s.logger.Error("Store", "iterateUDCHolders", err.Error())
+		return
+	}
+	defer itr.Close()
+
+	for ; itr.Valid() && bytes.HasPrefix(itr.Key(), prefix); itr.Next() {
+		addr := make(tm.Address, len(itr.Key())-len(prefix))
+		copy(addr, itr.Key()[len(prefix):])
+		if fn(addr) {
+			return
+		}
+	}
+}
+
+// GetUDCHolders returns at most num holders of the UDC with their balances
+// and locks as of the given height, along with the address of the next holder
+// to visit, if any.
+func (s Store) GetUDCHolders(udc uint32, from tm.Address, num int,
+	height int64, committed bool) ([]*types.UDCHolder, tm.Address) {
+	holders := []*types.UDCHolder{}
+	var next tm.Address
+	s.iterateUDCHolders(udc, from, func(addr tm.Address) bool {
+		if len(holders) >= num {
+			next = addr
+			return true
+		}
+		holder := &types.UDCHolder{
+			Address: addr,
+			Balance: *s.GetUDCBalance(udc, addr, committed),
+		}
+		lock := s.GetUDCLockSchedule(udc, addr, committed)
+		if lock.Amount.GreaterThan(new(types.Currency)) {
+			holder.Lock = &types.UDCLockEx{
+				UDCLock: lock,
+				Locked:  *lock.Locked(height),
+			}
+		}
+		holders = append(holders, holder)
+		return false
+	})
+	return holders, next
+}
+
+// GetUDCStats returns aggregate figures of the UDC as of the given height. An
+// amount locked in excess of the balance of a holder is not counted as
+// locked. As locks get released by height, the locked amount is summed up over
+// the holders having locks only.
+func (s Store) GetUDCStats(udc uint32, height int64, committed bool) *types.UDCStats {
+	u := s.GetUDC(udc, committed)
+	if u == nil {
+		return nil
+	}
+	stats := types.UDCStats{
+		Holders: s.getUDCHolderCount(udc),
+		Total:   u.Total,
+	}
+
+	prefix := ConvIDFromUint(udc)
+	itr, err := s.indexUDCLocker.Iterator(prefix, nil)
+	if err != nil {
+		s.logger.Error("Store", "GetUDCStats", err.Error())
+		return nil
+	}
+	defer itr.Close()
+	for ; itr.Valid() && bytes.HasPrefix(itr.Key(), prefix); itr.Next() {
+		addr := tm.Address(itr.Key()[len(prefix):])
+		locked := s.GetUDCLockSchedule(udc, addr, committed).Locked(height)
+		balance := s.GetUDCBalance(udc, addr, committed)
+		if locked.GreaterThan(balance) {
+			locked = balance
+		}
+		stats.Locked.Add(locked)
+	}
+
+	stats.Circulating.Int.Sub(&stats.Total.Int, &stats.Locked.Int)
+	if stats.Circulating.LessThan(new(types.Currency)) {
+		stats.Circulating = types.Currency{}
+	}
+	return &stats
+}
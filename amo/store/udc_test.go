@@ -1,6 +1,7 @@
 package store
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -16,13 +17,13 @@ func TestUDCSetGet(t *testing.T) {
 	assert.NotNil(t, s)
 
 	mycoin := &types.UDC{
-		makeAccAddr("issuer"),
-		"mycoin for test",
-		[]crypto.Address{
+		Owner: makeAccAddr("issuer"),
+		Desc:  "mycoin for test",
+		Operators: []crypto.Address{
 			makeAccAddr("op1"),
 			makeAccAddr("op2"),
 		},
-		*new(types.Currency).SetAMO(100),
+		Total: *new(types.Currency).SetAMO(100),
 	}
 	assert.NotNil(t, mycoin)
 
@@ -43,6 +44,40 @@ func TestUDCSetGet(t *testing.T) {
 	assert.Equal(t, mycoin, udc)
 }
 
+func TestUDCEncoding(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	// UDC as it was before protocol v6
+	legacy, err := json.Marshal(struct {
+		Owner     crypto.Address   `json:"owner"`
+		Desc      string           `json:"desc"`
+		Operators []crypto.Address `json:"operators"`
+		Total     types.Currency   `json:"total"`
+	}{
+		Owner:     makeAccAddr("issuer"),
+		Desc:      "mycoin for test",
+		Operators: []crypto.Address{makeAccAddr("op1")},
+		Total:     *new(types.Currency).SetAMO(100),
+	})
+	assert.NoError(t, err)
+
+	mycoin := &types.UDC{
+		Owner:     makeAccAddr("issuer"),
+		Desc:      "mycoin for test",
+		Operators: []crypto.Address{makeAccAddr("op1")},
+		Total:     *new(types.Currency).SetAMO(100),
+	}
+	assert.NoError(t, s.SetUDC(123, mycoin))
+	assert.Equal(t, legacy, s.get(getUDCKey(123), false))
+
+	// capped supply
+	mycoin.MaxSupply.SetAMO(1000)
+	assert.NoError(t, s.SetUDC(123, mycoin))
+	assert.Contains(t, string(s.get(getUDCKey(123), false)), `"max_supply":`)
+	assert.Equal(t, mycoin, s.GetUDC(123, false))
+}
+
 func TestUDCBalance(t *testing.T) {
 	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
 	assert.NoError(t, err)
@@ -79,4 +114,89 @@ func TestUDCLock(t *testing.T) {
 	k := append([]byte("udclock:"), 0x00, 0x00, 0x00, 0x7b, ':')
 	k = append(k, holder.Bytes()...)
 	assert.NotNil(t, s.get(k, false))
+
+	// lock stored in the older format
+	s.set(k, []byte(`"100"`))
+	assert.Equal(t, &types.UDCLock{
+		Amount: *new(types.Currency).Set(100),
+	}, s.GetUDCLockSchedule(udcid, holder, false))
+}
+
+func TestUDCHolderIndex(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	udcid := uint32(123)
+	s.SetUDC(udcid, &types.UDC{Total: *new(types.Currency).Set(1000)})
+	alice := makeAccAddr("alice")
+	bob := makeAccAddr("bob")
+	carol := makeAccAddr("carol")
+	dave := makeAccAddr("dave")
+	s.SetUDCBalance(udcid, alice, new(types.Currency).Set(500))
+	s.SetUDCBalance(udcid, bob, new(types.Currency).Set(300))
+	s.SetUDCBalance(udcid, carol, new(types.Currency).Set(200))
+	s.SetUDCLock(udcid, carol, new(types.Currency).Set(100))
+	// lock without balance
+	s.SetUDCLock(udcid, dave, new(types.Currency).Set(50))
+	// not holders of the UDC
+	s.SetUDCBalance(0, bob, new(types.Currency).Set(10))
+	s.SetUDCBalance(124, bob, new(types.Currency).Set(10))
+	s.SetUDCBalance(udcid, makeAccAddr("eve"), new(types.Currency).Set(0))
+
+	check := func() {
+		holders, next := s.GetUDCHolders(udcid, nil, 100, 0, false)
+		assert.Equal(t, 4, len(holders))
+		assert.Nil(t, next)
+
+		var all []*types.UDCHolder
+		var from crypto.Address
+		for {
+			holders, next = s.GetUDCHolders(udcid, from, 3, 0, false)
+			all = append(all, holders...)
+			if next == nil {
+				break
+			}
+			from = next
+		}
+		assert.Equal(t, 4, len(all))
+		for _, h := range all {
+			switch {
+			case h.Address.String() == carol.String():
+				assert.Equal(t, *new(types.Currency).Set(200), h.Balance)
+				assert.Equal(t, *new(types.Currency).Set(100), h.Lock.Locked)
+			case h.Address.String() == dave.String():
+				assert.Equal(t, types.Currency{}, h.Balance)
+				assert.NotNil(t, h.Lock)
+			default:
+				assert.Nil(t, h.Lock)
+			}
+		}
+
+		stats := s.GetUDCStats(udcid, 0, false)
+		assert.Equal(t, uint64(4), stats.Holders)
+		assert.Equal(t, *new(types.Currency).Set(100), stats.Locked)
+		assert.Equal(t, *new(types.Currency).Set(900), stats.Circulating)
+	}
+	check()
+
+	// rebuild
+	s.Save()
+	s.RebuildIndex()
+	check()
+
+	// holders leaving
+	s.SetUDCBalance(udcid, alice, new(types.Currency).Set(0))
+	s.SetUDCLock(udcid, dave, new(types.Currency).Set(0))
+	holders, _ := s.GetUDCHolders(udcid, nil, 100, 0, false)
+	assert.Equal(t, 2, len(holders))
+	assert.Equal(t, uint64(2), s.GetUDCStats(udcid, 0, false).Holders)
+	assert.Nil(t, s.GetUDCStats(124, 0, false))
+
+	// updates of existing holders are not counted again
+	s.SetUDCBalance(udcid, bob, new(types.Currency).Set(100))
+	s.SetUDCLock(udcid, carol, new(types.Currency).Set(0))
+	stats := s.GetUDCStats(udcid, 0, false)
+	assert.Equal(t, uint64(2), stats.Holders)
+	assert.Equal(t, types.Currency{}, stats.Locked)
 }
@@ -0,0 +1,85 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+type ApproveParam struct {
+	UDC     uint32         `json:"udc,omitempty"`
+	Spender crypto.Address `json:"spender"`
+	Amount  types.Currency `json:"amount"` // zero to remove the allowance
+}
+
+func parseApproveParam(raw []byte) (ApproveParam, error) {
+	var param ApproveParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxApprove struct {
+	TxBase
+	Param ApproveParam `json:"-"`
+}
+
+var _ Tx = &TxApprove{}
+
+func (t *TxApprove) Check() (uint32, string) {
+	txParam, err := parseApproveParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.Spender) != crypto.AddressSize {
+		return code.TxCodeBadParam, "wrong spender address size"
+	}
+	if bytes.Equal(t.GetSender(), txParam.Spender) {
+		return code.TxCodeSelfTransaction, "tried to approve self"
+	}
+	if txParam.Amount.LessThan(zero) {
+		return code.TxCodeInvalidAmount, "invalid amount"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxApprove) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	txParam := t.Param
+
+	if txParam.UDC != 0 && s.GetUDC(txParam.UDC, false) == nil {
+		return code.TxCodeUDCNotFound, "UDC not found", nil
+	}
+
+	err := s.SetUDCAllowance(txParam.UDC, t.GetSender(), txParam.Spender,
+		&txParam.Amount)
+	if err != nil {
+		return code.TxCodeUnknown, err.Error(), nil
+	}
+
+	return code.TxCodeOK, "ok", nil
+}
+
+// checkAllowance checks if the owner allowed the spender to move the amount
+// of coins, and returns the allowance after spending the amount.
+func checkAllowance(s *store.Store, udc uint32,
+	owner, spender crypto.Address, amount *types.Currency,
+) (*types.Currency, uint32, string) {
+	allowance := s.GetUDCAllowance(udc, owner, spender, false)
+	if allowance.LessThan(amount) {
+		return nil, code.TxCodeNotEnoughAllowance, "not enough allowance"
+	}
+	allowance.Sub(amount)
+	return allowance, code.TxCodeOK, "ok"
+}
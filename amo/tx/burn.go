@@ -55,7 +55,10 @@ func (t *TxBurn) Execute(s *store.Store) (uint32, string, []abci.Event) {
 		return code.TxCodeInvalidAmount, "invalid amount", nil
 	}
 
-	udcLock := s.GetUDCLock(param.UDC, t.GetSender(), false)
+	if rc, info := checkUDCFrozen(s, param.UDC, t.GetSender()); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	udcLock := getLockedUDC(s, param.UDC, t.GetSender())
 	balance := s.GetUDCBalance(param.UDC, t.GetSender(), false)
 	required := udcLock
 	required.Add(&param.Amount)
@@ -0,0 +1,90 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+type CancelAllParam struct {
+	Recipient crypto.Address `json:"recipient,omitempty"` // all if omitted
+}
+
+func parseCancelAllParam(raw []byte) (CancelAllParam, error) {
+	var param CancelAllParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+// TxCancelAll cancels all pending requests made by the sender as an agency.
+type TxCancelAll struct {
+	TxBase
+	Param CancelAllParam `json:"-"`
+}
+
+var _ Tx = &TxCancelAll{}
+
+func (t *TxCancelAll) Check() (uint32, string) {
+	txParam, err := parseCancelAllParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+
+	rpkSize := len(txParam.Recipient)
+	if rpkSize != 0 && rpkSize != crypto.AddressSize {
+		return code.TxCodeBadParam, "improper recipient address"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxCancelAll) Execute(store *store.Store) (uint32, string, []abci.Event) {
+	txParam := t.Param
+	agency := t.GetSender()
+
+	requests, _ := store.GetAgencyEntries(agency, false)
+
+	count := 0
+	refund := new(types.Currency).Set(0)
+	for _, r := range requests {
+		if len(txParam.Recipient) != 0 &&
+			!bytes.Equal(txParam.Recipient, r.Recipient) {
+			continue
+		}
+		store.DeleteRequest(r.Recipient, r.Target)
+		refund.Add(&r.Payment)
+		refund.Add(&r.DealerFee)
+		count += 1
+	}
+	if count == 0 {
+		return code.TxCodeRequestNotFound, "request not found", nil
+	}
+
+	balance := store.GetBalance(agency, false)
+	balance.Add(refund)
+	store.SetBalance(agency, balance)
+
+	countJson, _ := json.Marshal(count)
+	refundJson, _ := json.Marshal(refund)
+	events := []abci.Event{
+		abci.Event{
+			Type: "cancel_all",
+			Attributes: []kv.Pair{
+				{Key: []byte("count"), Value: countJson},
+				{Key: []byte("refund"), Value: refundJson},
+			},
+		},
+	}
+
+	return code.TxCodeOK, "ok", events
+}
@@ -0,0 +1,123 @@
+package tx
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+type ChallengeParam struct {
+	Target tmbytes.HexBytes `json:"target"`
+}
+
+func parseChallengeParam(raw []byte) (ChallengeParam, error) {
+	var param ChallengeParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxChallenge struct {
+	TxBase
+	Param ChallengeParam `json:"-"`
+}
+
+var _ Tx = &TxChallenge{}
+
+func (t *TxChallenge) Check() (uint32, string) {
+	txParam, err := parseChallengeParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.Target) <= types.StorageIDLen {
+		return code.TxCodeBadParam, "parcel id too short"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxChallenge) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	txParam := t.Param
+	if len(txParam.Target) <= types.StorageIDLen {
+		return code.TxCodeBadParam, "parcel id too short", nil
+	}
+
+	parcel := s.GetParcel(txParam.Target, false)
+	if parcel == nil {
+		return code.TxCodeParcelNotFound, "parcel not found", nil
+	}
+	if len(parcel.ContentRoot) == 0 || parcel.ChunkCount == 0 {
+		return code.TxCodeImproperTx, "parcel has no content root", nil
+	}
+
+	storageID := binary.BigEndian.Uint32(txParam.Target[:types.StorageIDLen])
+	storage := s.GetStorage(storageID, false)
+	if storage == nil || storage.Active == false {
+		return code.TxCodeNoStorage, "no active storage for this parcel", nil
+	}
+
+	if s.GetChallenge(txParam.Target, false) != nil {
+		return code.TxCodeAlreadyChallenged, "parcel already challenged", nil
+	}
+	// a parcel is challenged at most once in a challenge period
+	last := s.GetLastChallengeDeadline(txParam.Target, false)
+	if last > 0 && StateBlockHeight < last+ConfigAMOApp.ChallengePeriod {
+		return code.TxCodeAlreadyChallenged, "parcel challenged recently", nil
+	}
+
+	challenger := t.GetSender()
+	deposit := ConfigAMOApp.ChallengeDeposit
+	balance := s.GetBalance(challenger, false)
+	if balance.LessThan(&deposit) {
+		return code.TxCodeNotEnoughBalance, "not enough balance for deposit", nil
+	}
+	balance.Sub(&deposit)
+	s.SetBalance(challenger, balance)
+
+	// the content is kept as it is now, which re-register cannot change
+	challenge := types.Challenge{
+		Challenger:  challenger,
+		ContentRoot: parcel.ContentRoot,
+		ChunkCount:  parcel.ChunkCount,
+		Index:       challengeIndex(txParam.Target, parcel.ChunkCount),
+		Start:       StateBlockHeight,
+		Deadline:    StateBlockHeight + ConfigAMOApp.ChallengePeriod,
+		Deposit:     deposit,
+	}
+	s.SetChallenge(txParam.Target, &challenge)
+
+	parcelJson, _ := json.Marshal(txParam.Target)
+	indexJson, _ := json.Marshal(challenge.Index)
+	deadlineJson, _ := json.Marshal(challenge.Deadline)
+	events := []abci.Event{
+		abci.Event{
+			Type: "challenge",
+			Attributes: []kv.Pair{
+				{Key: []byte("parcel"), Value: parcelJson},
+				{Key: []byte("index"), Value: indexJson},
+				{Key: []byte("deadline"), Value: deadlineJson},
+			},
+		},
+	}
+
+	return code.TxCodeOK, "ok", events
+}
+
+// challengeIndex derives a chunk index from the hash of the current block, so
+// that neither the challenger nor the storage owner can choose it in advance.
+func challengeIndex(parcelID []byte, chunkCount uint64) uint64 {
+	seed := append([]byte{}, StateBlockHash...)
+	seed = append(seed, parcelID...)
+	h := tmhash.Sum(seed)
+	return binary.BigEndian.Uint64(h[:8]) % chunkCount
+}
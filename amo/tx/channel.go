@@ -0,0 +1,196 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+// maxChannelPeriod bounds the challenge period so that the end height of a
+// closing channel never overflows.
+const maxChannelPeriod = int64(1000000)
+
+func makeChannelEvent(id uint64, status string) abci.Event {
+	idJson, _ := json.Marshal(id)
+	statusJson, _ := json.Marshal(status)
+	return abci.Event{
+		Type: "channel",
+		Attributes: []kv.Pair{
+			{Key: []byte("id"), Value: idJson},
+			{Key: []byte("status"), Value: statusJson},
+		},
+	}
+}
+
+//// channel_open
+
+type ChannelOpenParam struct {
+	Recipient crypto.Address `json:"recipient"`
+	UDC       uint32         `json:"udc,omitempty"`
+	Amount    types.Currency `json:"amount"`
+	Period    int64          `json:"period"` // challenge period in blocks
+}
+
+func parseChannelOpenParam(raw []byte) (ChannelOpenParam, error) {
+	var param ChannelOpenParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxChannelOpen struct {
+	TxBase
+	Param ChannelOpenParam `json:"-"`
+}
+
+var _ Tx = &TxChannelOpen{}
+
+func (t *TxChannelOpen) Check() (uint32, string) {
+	txParam, err := parseChannelOpenParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.Recipient) != crypto.AddressSize {
+		return code.TxCodeBadParam, "wrong recipient address size"
+	}
+	if bytes.Equal(t.GetSender(), txParam.Recipient) {
+		return code.TxCodeSelfTransaction, "tried to open a channel to self"
+	}
+	if !txParam.Amount.GreaterThan(zero) {
+		return code.TxCodeInvalidAmount, "invalid amount"
+	}
+	if txParam.Period <= 0 || txParam.Period > maxChannelPeriod {
+		return code.TxCodeBadParam, "invalid challenge period"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxChannelOpen) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	txParam := t.Param
+
+	if rc, info := checkUDCFrozen(s, txParam.UDC, txParam.Recipient); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	if rc, info := escrowCoin(s, txParam.UDC, t.GetSender(),
+		&txParam.Amount); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+
+	id := s.NextChannelID()
+	err := s.SetChannel(id, &types.Channel{
+		Sender:    t.GetSender(),
+		Recipient: txParam.Recipient,
+		UDC:       txParam.UDC,
+		Deposit:   txParam.Amount,
+		Period:    txParam.Period,
+	})
+	if err != nil {
+		return code.TxCodeUnknown, err.Error(), nil
+	}
+
+	return code.TxCodeOK, "ok", []abci.Event{
+		makeChannelEvent(id, "open"),
+	}
+}
+
+//// channel_close
+
+type ChannelCloseParam struct {
+	ID      uint64         `json:"id"`
+	Voucher *types.Voucher `json:"voucher,omitempty"` // latest one, if any
+}
+
+func parseChannelCloseParam(raw []byte) (ChannelCloseParam, error) {
+	var param ChannelCloseParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxChannelClose struct {
+	TxBase
+	Param ChannelCloseParam `json:"-"`
+}
+
+var _ Tx = &TxChannelClose{}
+
+func (t *TxChannelClose) Check() (uint32, string) {
+	txParam, err := parseChannelCloseParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if txParam.Voucher != nil && txParam.Voucher.Channel != txParam.ID {
+		return code.TxCodeBadParam, "voucher for another channel"
+	}
+	if txParam.Voucher != nil && txParam.Voucher.Amount.Sign() < 0 {
+		return code.TxCodeInvalidAmount, "invalid voucher amount"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+// Execute starts the challenge period of the channel. During the period,
+// either party may submit a voucher higher than the one submitted before.
+func (t *TxChannelClose) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	txParam := t.Param
+
+	channel := s.GetChannel(txParam.ID, false)
+	if channel == nil {
+		return code.TxCodeChannelNotFound, "channel not found", nil
+	}
+	sender := t.GetSender()
+	if !bytes.Equal(sender, channel.Sender) &&
+		!bytes.Equal(sender, channel.Recipient) {
+		return code.TxCodePermissionDenied, "permission denied", nil
+	}
+
+	voucher := txParam.Voucher
+	if voucher != nil {
+		if !voucher.Verify(channel.Sender) {
+			return code.TxCodeBadSignature, "bad voucher signature", nil
+		}
+		if voucher.Amount.GreaterThan(&channel.Deposit) {
+			return code.TxCodeBadParam, "voucher exceeds deposit", nil
+		}
+	}
+
+	status := "closing"
+	if channel.Closing() {
+		if voucher == nil || !voucher.Amount.GreaterThan(&channel.Paid) {
+			return code.TxCodeBadParam, "no higher voucher", nil
+		}
+		status = "challenged"
+	} else {
+		channel.End = StateBlockHeight + channel.Period
+	}
+	if voucher != nil {
+		channel.Paid = voucher.Amount
+	}
+
+	err := s.SetChannel(txParam.ID, channel)
+	if err != nil {
+		return code.TxCodeUnknown, err.Error(), nil
+	}
+
+	return code.TxCodeOK, "ok", []abci.Event{
+		makeChannelEvent(txParam.ID, status),
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/amolabs/amoabci/amo/code"
 	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
 )
 
 type CloseParam struct {
@@ -54,6 +55,24 @@ func (t *TxClose) Execute(s *store.Store) (uint32, string, []abci.Event) {
 		// update fields
 		sto.Active = false
 	}
+	// bond gets locked for a while after closing storage
+	if sto.Bond.GreaterThan(zero) {
+		bond := &types.StorageBond{
+			Owner:  sto.Owner,
+			Amount: sto.Bond,
+		}
+		prev := s.GetLockedStorageBond(param.Storage,
+			ConfigAMOApp.LockupPeriod, false)
+		if prev != nil {
+			bond.Amount.Add(&prev.Amount)
+		}
+		err := s.SetLockedStorageBond(param.Storage, bond,
+			ConfigAMOApp.LockupPeriod)
+		if err != nil {
+			return code.TxCodeUnknown, err.Error(), nil
+		}
+		sto.Bond.Set(0)
+	}
 	// store
 	err := s.SetStorage(param.Storage, sto)
 	if err != nil {
@@ -0,0 +1,194 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+// checkIssuer checks if the sender controls the issuer DID, either as the
+// owner of the DID or by sending the tx as the DID.
+func checkIssuer(s *store.Store, issuer string, sender crypto.Address) (uint32, string) {
+	entry := s.GetDIDEntry(issuer, false)
+	if entry == nil {
+		return code.TxCodeDIDNotFound, "DID not found"
+	}
+	if entry.Deactivated {
+		return code.TxCodeDIDDeactivated, "DID deactivated"
+	}
+	if !bytes.Equal(entry.Owner, sender) &&
+		!bytes.Equal(types.DIDAddress(issuer), sender) {
+		return code.TxCodePermissionDenied, "permission denied"
+	}
+	return code.TxCodeOK, "ok"
+}
+
+func makeCredentialStatusEvent(issuer, credential, status string) abci.Event {
+	issuerJson, _ := json.Marshal(issuer)
+	credentialJson, _ := json.Marshal(credential)
+	statusJson, _ := json.Marshal(status)
+	return abci.Event{
+		Type: "credential_status",
+		Attributes: []kv.Pair{
+			{Key: []byte("issuer"), Value: issuerJson},
+			{Key: []byte("credential"), Value: credentialJson},
+			{Key: []byte("status"), Value: statusJson},
+		},
+	}
+}
+
+//// revoke_credential
+
+type RevokeCredentialParam struct {
+	Issuer     string `json:"issuer"`
+	Credential string `json:"credential"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+func parseRevokeCredentialParam(raw []byte) (RevokeCredentialParam, error) {
+	var param RevokeCredentialParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxRevokeCredential struct {
+	TxBase
+	Param RevokeCredentialParam `json:"-"`
+}
+
+var _ Tx = &TxRevokeCredential{}
+
+func (t *TxRevokeCredential) Check() (uint32, string) {
+	txParam, err := parseRevokeCredentialParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if err := types.CheckDID(txParam.Issuer); err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.Credential) == 0 {
+		return code.TxCodeBadParam, "empty credential"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxRevokeCredential) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	txParam := t.Param
+
+	rc, info := checkIssuer(s, txParam.Issuer, t.GetSender())
+	if rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+
+	status := s.GetCredentialStatus(txParam.Issuer, txParam.Credential, false)
+	if status != nil && status.Status == types.CredentialRevoked {
+		return code.TxCodeCredentialRevoked, "already revoked", nil
+	}
+
+	// revocation is permanent
+	s.SetCredentialStatus(txParam.Issuer, txParam.Credential,
+		&types.CredentialStatus{
+			Status: types.CredentialRevoked,
+			Reason: txParam.Reason,
+			Height: StateBlockHeight,
+		})
+
+	return code.TxCodeOK, "ok", []abci.Event{
+		makeCredentialStatusEvent(txParam.Issuer, txParam.Credential,
+			types.CredentialRevoked),
+	}
+}
+
+//// suspend_credential
+
+type SuspendCredentialParam struct {
+	Issuer     string `json:"issuer"`
+	Credential string `json:"credential"`
+	Reason     string `json:"reason,omitempty"`
+	Lift       bool   `json:"lift,omitempty"` // true to lift the suspension
+}
+
+func parseSuspendCredentialParam(raw []byte) (SuspendCredentialParam, error) {
+	var param SuspendCredentialParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxSuspendCredential struct {
+	TxBase
+	Param SuspendCredentialParam `json:"-"`
+}
+
+var _ Tx = &TxSuspendCredential{}
+
+func (t *TxSuspendCredential) Check() (uint32, string) {
+	txParam, err := parseSuspendCredentialParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if err := types.CheckDID(txParam.Issuer); err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.Credential) == 0 {
+		return code.TxCodeBadParam, "empty credential"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxSuspendCredential) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	txParam := t.Param
+
+	rc, info := checkIssuer(s, txParam.Issuer, t.GetSender())
+	if rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+
+	status := s.GetCredentialStatus(txParam.Issuer, txParam.Credential, false)
+	if status != nil && status.Status == types.CredentialRevoked {
+		return code.TxCodeCredentialRevoked, "already revoked", nil
+	}
+
+	if txParam.Lift {
+		if status == nil {
+			return code.TxCodeNotFound, "not suspended", nil
+		}
+		s.DeleteCredentialStatus(txParam.Issuer, txParam.Credential)
+		return code.TxCodeOK, "ok", []abci.Event{
+			makeCredentialStatusEvent(txParam.Issuer, txParam.Credential,
+				types.CredentialActive),
+		}
+	}
+
+	s.SetCredentialStatus(txParam.Issuer, txParam.Credential,
+		&types.CredentialStatus{
+			Status: types.CredentialSuspended,
+			Reason: txParam.Reason,
+			Height: StateBlockHeight,
+		})
+
+	return code.TxCodeOK, "ok", []abci.Event{
+		makeCredentialStatusEvent(txParam.Issuer, txParam.Credential,
+			types.CredentialSuspended),
+	}
+}
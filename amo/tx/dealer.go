@@ -0,0 +1,71 @@
+package tx
+
+import (
+	"encoding/json"
+	"math"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+type DealerParam struct {
+	MaxFeeRate float64         `json:"max_fee_rate"`
+	Metadata   json.RawMessage `json:"metadata,omitempty"`
+}
+
+func parseDealerParam(raw []byte) (DealerParam, error) {
+	var param DealerParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+func validFeeRate(rate float64) bool {
+	if math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return false
+	}
+	return rate >= 0 && rate <= 1
+}
+
+type TxDealer struct {
+	TxBase
+	Param DealerParam `json:"-"`
+}
+
+var _ Tx = &TxDealer{}
+
+func (t *TxDealer) Check() (uint32, string) {
+	txParam, err := parseDealerParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if !validFeeRate(txParam.MaxFeeRate) {
+		return code.TxCodeBadParam, "invalid max fee rate"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+// Execute registers the sender as a dealer, or updates the dealer while
+// keeping its statistics.
+func (t *TxDealer) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	txParam := t.Param
+	if !validFeeRate(txParam.MaxFeeRate) {
+		return code.TxCodeBadParam, "invalid max fee rate", nil
+	}
+
+	dealer := s.GetDealer(t.GetSender(), false)
+	if dealer == nil {
+		dealer = new(types.Dealer)
+	}
+	dealer.MaxFeeRate = txParam.MaxFeeRate
+	dealer.Metadata = txParam.Metadata
+	s.SetDealer(t.GetSender(), dealer)
+
+	return code.TxCodeOK, "ok", []abci.Event{}
+}
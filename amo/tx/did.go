@@ -1,11 +1,12 @@
 package tx
 
 import (
-	//"bytes"
+	"bytes"
 	//"encoding/binary"
 	"encoding/json"
 
 	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
 
 	"github.com/amolabs/amoabci/amo/code"
 	"github.com/amolabs/amoabci/amo/store"
@@ -36,24 +37,57 @@ type TxClaim struct {
 var _ Tx = &TxClaim{}
 
 func (t *TxClaim) Check() (uint32, string) {
-	_, err := parseClaimParam(t.getPayload())
+	txParam, err := parseClaimParam(t.getPayload())
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
+	// DID and its document are validated from protocol v6
+	if !protocolV6() {
+		return code.TxCodeOK, "ok"
+	}
+	if err := types.CheckDID(txParam.Target); err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	_, err = types.ParseDIDDocument(txParam.Target, txParam.Document)
+	if err != nil {
+		return code.TxCodeBadDIDDocument, err.Error()
+	}
 
 	return code.TxCodeOK, "ok"
 }
 
 func (t *TxClaim) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseClaimParam(t.getPayload())
-	if err != nil {
-		return code.TxCodeBadParam, err.Error(), nil
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	txParam := t.Param
+
+	if !protocolV6() {
+		store.SetDIDEntry(txParam.Target, &types.DIDEntry{
+			Owner:    t.GetSender(),
+			Document: txParam.Document,
+		})
+		return code.TxCodeOK, "ok", []abci.Event{}
 	}
 
-	entry := &types.DIDEntry{
-		Owner:    t.GetSender(),
-		Document: txParam.Document,
+	// only the current owner can update an existing DID
+	entry := store.GetDIDEntry(txParam.Target, false)
+	if entry == nil {
+		entry = &types.DIDEntry{
+			Owner:   t.GetSender(),
+			Created: StateBlockHeight,
+		}
+	} else {
+		if !bytes.Equal(entry.Owner, t.GetSender()) {
+			return code.TxCodePermissionDenied, "permission denied", nil
+		}
+		if entry.Deactivated {
+			return code.TxCodeDIDDeactivated, "DID deactivated", nil
+		}
+		entry.Updated = StateBlockHeight
 	}
+	entry.Version += 1
+	entry.Document = txParam.Document
 	store.SetDIDEntry(txParam.Target, entry)
 
 	return code.TxCodeOK, "ok", []abci.Event{}
@@ -82,21 +116,137 @@ type TxDismiss struct {
 var _ Tx = &TxDismiss{}
 
 func (t *TxDismiss) Check() (uint32, string) {
-	_, err := parseDismissParam(t.getPayload())
+	txParam, err := parseDismissParam(t.getPayload())
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
+	if protocolV6() && len(txParam.Target) == 0 {
+		return code.TxCodeBadParam, "empty target"
+	}
 
 	return code.TxCodeOK, "ok"
 }
 
 func (t *TxDismiss) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseClaimParam(t.getPayload())
+	txParam, err := parseDismissParam(t.getPayload())
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
 
-	store.DeleteDIDEntry(txParam.Target)
+	if !protocolV6() {
+		store.DeleteDIDEntry(txParam.Target)
+		return code.TxCodeOK, "ok", []abci.Event{}
+	}
+
+	entry := store.GetDIDEntry(txParam.Target, false)
+	if entry == nil {
+		return code.TxCodeDIDNotFound, "DID not found", nil
+	}
+	if !bytes.Equal(entry.Owner, t.GetSender()) {
+		return code.TxCodePermissionDenied, "permission denied", nil
+	}
+	if entry.Deactivated {
+		return code.TxCodeDIDDeactivated, "DID deactivated", nil
+	}
+
+	// keep the entry so that a deactivated DID cannot be claimed again
+	entry.Document = nil
+	entry.Deactivated = true
+	entry.Updated = StateBlockHeight
+	entry.Version += 1
+	store.SetDIDEntry(txParam.Target, entry)
 
 	return code.TxCodeOK, "ok", []abci.Event{}
 }
+
+//// transfer_did
+
+type TransferDIDParam struct {
+	Target string         `json:"target"`
+	Owner  crypto.Address `json:"owner"`
+}
+
+func parseTransferDIDParam(raw []byte) (TransferDIDParam, error) {
+	var param TransferDIDParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxTransferDID struct {
+	TxBase
+	Param TransferDIDParam `json:"-"`
+}
+
+var _ Tx = &TxTransferDID{}
+
+func (t *TxTransferDID) Check() (uint32, string) {
+	txParam, err := parseTransferDIDParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.Target) == 0 {
+		return code.TxCodeBadParam, "empty target"
+	}
+	if len(txParam.Owner) != crypto.AddressSize {
+		return code.TxCodeBadParam, "wrong owner address size"
+	}
+	if bytes.Equal(txParam.Owner, t.GetSender()) {
+		return code.TxCodeSelfTransaction, "tried to transfer to self"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxTransferDID) Execute(store *store.Store) (uint32, string, []abci.Event) {
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	txParam := t.Param
+
+	entry := store.GetDIDEntry(txParam.Target, false)
+	if entry == nil {
+		return code.TxCodeDIDNotFound, "DID not found", nil
+	}
+	if !bytes.Equal(entry.Owner, t.GetSender()) {
+		return code.TxCodePermissionDenied, "permission denied", nil
+	}
+	if entry.Deactivated {
+		return code.TxCodeDIDDeactivated, "DID deactivated", nil
+	}
+
+	entry.Owner = txParam.Owner
+	entry.Updated = StateBlockHeight
+	entry.Version += 1
+	store.SetDIDEntry(txParam.Target, entry)
+
+	return code.TxCodeOK, "ok", []abci.Event{}
+}
+
+// verifyDIDKey checks if the tx is signed with a key which the DID document of
+// the sender authorizes for authentication.
+func (t *TxBase) verifyDIDKey(s *store.Store) bool {
+	if s == nil || !bytes.Equal(t.Sender, types.DIDAddress(t.DID)) {
+		return false
+	}
+	entry := s.GetDIDEntry(t.DID, true)
+	if entry == nil || entry.Deactivated {
+		return false
+	}
+	doc, err := types.ParseDIDDocument(t.DID, entry.Document)
+	if err != nil {
+		return false
+	}
+	vm := doc.GetAuthenticationMethod(t.Signature.KeyID)
+	if vm == nil || vm.PublicKeyJwk == nil {
+		return false
+	}
+	x, y, err := vm.PublicKeyJwk.P256Point()
+	if err != nil {
+		return false
+	}
+	pubKey := t.Signature.PubKey.ToECDSA()
+	return pubKey.X.Cmp(x) == 0 && pubKey.Y.Cmp(y) == 0
+}
@@ -2,6 +2,7 @@ package tx
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"testing"
 
@@ -10,6 +11,8 @@ import (
 
 	"github.com/amolabs/amoabci/amo/code"
 	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+	"github.com/amolabs/amoabci/crypto/p256"
 )
 
 func TestTxClaim(t *testing.T) {
@@ -18,13 +21,13 @@ func TestTxClaim(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, s)
 
-	entry := s.GetDIDEntry("myid", false)
+	entry := s.GetDIDEntry("did:amo:myid", false)
 	assert.Nil(t, entry)
 
 	// first claim
 	payload, _ := json.Marshal(ClaimParam{
-		Target:   "myid",
-		Document: []byte(`{}`),
+		Target:   "did:amo:myid",
+		Document: []byte(`{"id":"did:amo:myid"}`),
 	})
 	t1 := makeTestTx("claim", "sender", payload)
 	rc, info := t1.Check()
@@ -34,31 +37,42 @@ func TestTxClaim(t *testing.T) {
 	assert.Equal(t, code.TxCodeOK, rc)
 	assert.Equal(t, "ok", info)
 
-	entry = s.GetDIDEntry("myid", false)
+	entry = s.GetDIDEntry("did:amo:myid", false)
 	assert.NotNil(t, entry)
 	assert.True(t, bytes.Equal(makeAccAddr("sender"), entry.Owner))
-	assert.True(t, bytes.Equal([]byte(`{}`), entry.Document))
+	assert.True(t, bytes.Equal([]byte(`{"id":"did:amo:myid"}`), entry.Document))
+
+	// claim by other
+	payload, _ = json.Marshal(ClaimParam{
+		Target:   "did:amo:myid",
+		Document: []byte(`{"id": "did:amo:myid", "haha": "hoho"}`),
+	})
+	rc, _, _ = makeTestTx("claim", "other", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
 
 	// update claim
 	payload, _ = json.Marshal(ClaimParam{
-		Target:   "myid",
-		Document: []byte(`{"haha": "hoho"}`),
+		Target:   "did:amo:myid",
+		Document: []byte(`{"id": "did:amo:myid", "haha": "hoho"}`),
 	})
 	t2 := makeTestTx("claim", "sender", payload)
 	rc, _, _ = t2.Execute(s)
 	assert.Equal(t, code.TxCodeOK, rc)
 	assert.Equal(t, "ok", info)
 
-	entry = s.GetDIDEntry("myid", false)
+	entry = s.GetDIDEntry("did:amo:myid", false)
 	assert.NotNil(t, entry)
 	assert.True(t, bytes.Equal(makeAccAddr("sender"), entry.Owner))
 	// XXX note that retrieved document is a compact representation
-	assert.True(t, bytes.Equal([]byte(`{"haha":"hoho"}`), entry.Document))
+	assert.True(t, bytes.Equal([]byte(`{"id":"did:amo:myid","haha":"hoho"}`),
+		entry.Document))
 
 	// dsmiss
 	payload, _ = json.Marshal(DismissParam{
-		Target: "myid",
+		Target: "did:amo:myid",
 	})
+	rc, _, _ = makeTestTx("dismiss", "other", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
 	t3 := makeTestTx("dismiss", "sender", payload)
 	rc, info = t3.Check()
 	assert.Equal(t, code.TxCodeOK, rc)
@@ -67,6 +81,352 @@ func TestTxClaim(t *testing.T) {
 	assert.Equal(t, code.TxCodeOK, rc)
 	assert.Equal(t, "ok", info)
 
-	entry = s.GetDIDEntry("myid", false)
-	assert.Nil(t, entry)
+	entry = s.GetDIDEntry("did:amo:myid", false)
+	assert.NotNil(t, entry)
+	assert.True(t, entry.Deactivated)
+
+	// deactivated DID cannot be claimed again
+	payload, _ = json.Marshal(ClaimParam{
+		Target:   "did:amo:myid",
+		Document: []byte(`{"id":"did:amo:myid"}`),
+	})
+	rc, _, _ = makeTestTx("claim", "sender", payload).Execute(s)
+	assert.Equal(t, code.TxCodeDIDDeactivated, rc)
+	rc, _, _ = makeTestTx("claim", "other", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+}
+
+func makeTestDIDDocument(id, seed string) []byte {
+	pubKey := makeTestPubKey(seed)
+	doc := map[string]interface{}{
+		"@context": "https://www.w3.org/ns/did/v1",
+		"id":       id,
+		"verificationMethod": []interface{}{
+			map[string]interface{}{
+				"id":         id + "#key-1",
+				"type":       "JsonWebKey2020",
+				"controller": id,
+				"publicKeyJwk": map[string]string{
+					"kty": "EC",
+					"crv": "P-256",
+					"x":   base64.RawURLEncoding.EncodeToString(pubKey[1:33]),
+					"y":   base64.RawURLEncoding.EncodeToString(pubKey[33:]),
+				},
+			},
+		},
+		"authentication": []string{"#key-1"},
+	}
+	b, _ := json.Marshal(doc)
+	return b
+}
+
+func TestTxClaimDocument(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	StateBlockHeight = 10
+	defer func() { StateBlockHeight = defaultBlockHeight }()
+
+	doc := makeTestDIDDocument("did:amo:myid", "sender")
+	for _, bad := range []struct {
+		target string
+		doc    string
+		rc     uint32
+	}{
+		{"myid", string(doc), code.TxCodeBadParam},
+		{"did:other:myid", string(doc), code.TxCodeBadParam},
+		{"did:amo:yourid", string(doc), code.TxCodeBadDIDDocument},
+		{"did:amo:myid", `"did:amo:myid"`, code.TxCodeBadDIDDocument},
+		// malformed controller
+		{"did:amo:myid", `{"id":"did:amo:myid","controller":"me"}`,
+			code.TxCodeBadDIDDocument},
+		// no key material
+		{"did:amo:myid", `{"id":"did:amo:myid","verificationMethod":[` +
+			`{"id":"#key-1","type":"JsonWebKey2020",` +
+			`"controller":"did:amo:myid"}]}`,
+			code.TxCodeBadDIDDocument},
+		// point not on curve
+		{"did:amo:myid", `{"id":"did:amo:myid","verificationMethod":[` +
+			`{"id":"#key-1","type":"JsonWebKey2020",` +
+			`"controller":"did:amo:myid","publicKeyJwk":{` +
+			`"kty":"EC","crv":"P-256",` +
+			`"x":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",` +
+			`"y":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"}}]}`,
+			code.TxCodeBadDIDDocument},
+		// private key
+		{"did:amo:myid", `{"id":"did:amo:myid","verificationMethod":[` +
+			`{"id":"#key-1","type":"JsonWebKey2020",` +
+			`"controller":"did:amo:myid","publicKeyJwk":{` +
+			`"kty":"OKP","crv":"Ed25519","x":"AA","d":"AA"}}]}`,
+			code.TxCodeBadDIDDocument},
+		// unknown verification method
+		{"did:amo:myid", `{"id":"did:amo:myid",` +
+			`"authentication":["#key-2"]}`,
+			code.TxCodeBadDIDDocument},
+	} {
+		payload, _ := json.Marshal(ClaimParam{
+			Target:   bad.target,
+			Document: []byte(bad.doc),
+		})
+		rc, _ := makeTestTx("claim", "sender", payload).Check()
+		assert.Equal(t, bad.rc, rc, bad.doc)
+	}
+
+	payload, _ := json.Marshal(ClaimParam{
+		Target:   "did:amo:myid",
+		Document: doc,
+	})
+	t1 := makeTestTx("claim", "sender", payload)
+	rc, _ := t1.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	entry := s.GetDIDEntry("did:amo:myid", false)
+	assert.Equal(t, int64(10), entry.Created)
+	assert.Equal(t, int64(0), entry.Updated)
+
+	StateBlockHeight = 20
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	entry = s.GetDIDEntry("did:amo:myid", false)
+	assert.Equal(t, int64(10), entry.Created)
+	assert.Equal(t, int64(20), entry.Updated)
+	assert.False(t, entry.Deactivated)
+	assert.Equal(t, uint64(2), entry.Version)
+
+	// previous version remains
+	prev := s.GetDIDEntryVersion("did:amo:myid", 1, false)
+	assert.NotNil(t, prev)
+	assert.Equal(t, int64(0), prev.Updated)
+	assert.Equal(t, doc, []byte(prev.Document))
+
+	payload, _ = json.Marshal(DismissParam{Target: "did:amo:myid"})
+	StateBlockHeight = 30
+	rc, _, _ = makeTestTx("dismiss", "sender", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, 3, len(s.GetDIDVersions("did:amo:myid", false)))
+	prev = s.GetDIDEntryAt("did:amo:myid", 29, false)
+	assert.Equal(t, uint64(2), prev.Version)
+	assert.False(t, prev.Deactivated)
+}
+
+func TestTxTransferDID(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	payload, _ := json.Marshal(TransferDIDParam{
+		Target: "did:amo:myid",
+		Owner:  makeAccAddr("sender"),
+	})
+	rc, _ := makeTestTxV6("transfer_did", "sender", payload).Check()
+	assert.Equal(t, code.TxCodeSelfTransaction, rc)
+
+	payload, _ = json.Marshal(TransferDIDParam{
+		Target: "did:amo:myid",
+		Owner:  makeAccAddr("other"),
+	})
+	t1 := makeTestTxV6("transfer_did", "sender", payload)
+	rc, _ = t1.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeDIDNotFound, rc)
+
+	payload, _ = json.Marshal(ClaimParam{
+		Target:   "did:amo:myid",
+		Document: []byte(`{"id":"did:amo:myid"}`),
+	})
+	rc, _, _ = makeTestTx("claim", "sender", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+
+	// not an owner
+	payload, _ = json.Marshal(TransferDIDParam{
+		Target: "did:amo:myid",
+		Owner:  makeAccAddr("other"),
+	})
+	rc, _, _ = makeTestTxV6("transfer_did", "thief", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	entry := s.GetDIDEntry("did:amo:myid", false)
+	assert.NotNil(t, entry)
+	assert.True(t, bytes.Equal(makeAccAddr("other"), entry.Owner))
+	assert.True(t, bytes.Equal([]byte(`{"id":"did:amo:myid"}`), entry.Document))
+
+	// previous owner lost control
+	payload, _ = json.Marshal(DismissParam{
+		Target: "did:amo:myid",
+	})
+	rc, _, _ = makeTestTx("dismiss", "sender", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+	rc, _, _ = makeTestTx("dismiss", "other", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = makeTestTx("dismiss", "other", payload).Execute(s)
+	assert.Equal(t, code.TxCodeDIDDeactivated, rc)
+}
+
+func TestTxSignatureDID(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	did := "did:amo:myid"
+	key1 := p256.GenPrivKeyFromSecret([]byte("key1"))
+	key2 := p256.GenPrivKeyFromSecret([]byte("key2"))
+	payload, _ := json.Marshal(TransferParam{
+		To:     makeAccAddr("recipient"),
+		Amount: *new(types.Currency).Set(1000),
+	})
+	trnx := &TxBase{
+		Type:       "transfer",
+		Sender:     types.DIDAddress(did),
+		DID:        did,
+		Payload:    payload,
+		LastHeight: "1",
+		Signature:  Signature{KeyID: "#key-1"},
+	}
+	err = trnx.Sign(key1)
+	assert.NoError(t, err)
+	assert.Equal(t, "#key-1", trnx.Signature.KeyID)
+
+	// DID not claimed yet
+	assert.False(t, trnx.Verify(s))
+
+	payload, _ = json.Marshal(ClaimParam{
+		Target:   did,
+		Document: makeTestDIDDocument(did, "key1"),
+	})
+	rc, _, _ := makeTestTx("claim", "owner", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	s.Save()
+	assert.True(t, trnx.Verify(s))
+	assert.False(t, trnx.Verify(nil))
+
+	// unknown key id
+	trnx.Signature.KeyID = "#key-2"
+	assert.False(t, trnx.Verify(s))
+	trnx.Signature.KeyID = "#key-1"
+
+	// wrong sender address
+	trnx.Sender = key1.PubKey().Address()
+	assert.False(t, trnx.Verify(s))
+	trnx.Sender = types.DIDAddress(did)
+
+	// not authorized key
+	err = trnx.Sign(key2)
+	assert.NoError(t, err)
+	assert.False(t, trnx.Verify(s))
+
+	// key rotation
+	payload, _ = json.Marshal(ClaimParam{
+		Target:   did,
+		Document: makeTestDIDDocument(did, "key2"),
+	})
+	rc, _, _ = makeTestTx("claim", "owner", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	s.Save()
+	assert.True(t, trnx.Verify(s))
+
+	// deactivated
+	payload, _ = json.Marshal(DismissParam{Target: did})
+	rc, _, _ = makeTestTx("dismiss", "owner", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	s.Save()
+	assert.False(t, trnx.Verify(s))
+}
+
+func TestTxCredentialStatus(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	issuer := "did:amo:issuer"
+	payload, _ := json.Marshal(SuspendCredentialParam{
+		Issuer:     issuer,
+		Credential: "urn:uuid:1234",
+	})
+	t1 := makeTestTxV6("suspend_credential", "owner", payload)
+	_, ok := t1.(*TxSuspendCredential)
+	assert.True(t, ok)
+	rc, _ := t1.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeDIDNotFound, rc)
+
+	payload, _ = json.Marshal(ClaimParam{
+		Target:   issuer,
+		Document: []byte(`{"id":"did:amo:issuer"}`),
+	})
+	rc, _, _ = makeTestTx("claim", "owner", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+
+	// not an issuer
+	rc, _, _ = makeTestTxV6("suspend_credential", "other", t1.getPayload()).
+		Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+
+	// suspend and lift
+	rc, _, evs := t1.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, 1, len(evs))
+	status := s.GetCredentialStatus(issuer, "urn:uuid:1234", false)
+	assert.Equal(t, types.CredentialSuspended, status.Status)
+
+	payload, _ = json.Marshal(SuspendCredentialParam{
+		Issuer:     issuer,
+		Credential: "urn:uuid:1234",
+		Lift:       true,
+	})
+	t2 := makeTestTxV6("suspend_credential", "owner", payload)
+	rc, _, _ = t2.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Nil(t, s.GetCredentialStatus(issuer, "urn:uuid:1234", false))
+	rc, _, _ = t2.Execute(s)
+	assert.Equal(t, code.TxCodeNotFound, rc)
+
+	// revoke as the DID
+	payload, _ = json.Marshal(RevokeCredentialParam{
+		Issuer:     "issuer",
+		Credential: "urn:uuid:1234",
+	})
+	rc, _ = makeTestTxV6("revoke_credential", "owner", payload).Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+
+	StateBlockHeight = 10
+	defer func() { StateBlockHeight = defaultBlockHeight }()
+	payload, _ = json.Marshal(RevokeCredentialParam{
+		Issuer:     issuer,
+		Credential: "urn:uuid:1234",
+		Reason:     "compromised",
+	})
+	t3 := &TxRevokeCredential{
+		TxBase: TxBase{
+			Type:    "revoke_credential",
+			Sender:  types.DIDAddress(issuer),
+			DID:     issuer,
+			Payload: payload,
+		},
+	}
+	t3.Param, _ = parseRevokeCredentialParam(payload)
+	rc, _, _ = t3.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, &types.CredentialStatus{
+		Status: types.CredentialRevoked,
+		Reason: "compromised",
+		Height: 10,
+	}, s.GetCredentialStatus(issuer, "urn:uuid:1234", false))
+
+	// revocation is permanent
+	rc, _, _ = t3.Execute(s)
+	assert.Equal(t, code.TxCodeCredentialRevoked, rc)
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeCredentialRevoked, rc)
+	rc, _, _ = t2.Execute(s)
+	assert.Equal(t, code.TxCodeCredentialRevoked, rc)
 }
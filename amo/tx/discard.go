@@ -1,7 +1,6 @@
 package tx
 
 import (
-	"bytes"
 	"encoding/json"
 
 	abci "github.com/tendermint/tendermint/abci/types"
@@ -9,6 +8,7 @@ import (
 
 	"github.com/amolabs/amoabci/amo/code"
 	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
 )
 
 type DiscardParam struct {
@@ -53,8 +53,7 @@ func (t *TxDiscard) Execute(store *store.Store) (uint32, string, []abci.Event) {
 		return code.TxCodeParcelNotFound, "parcel not found", nil
 	}
 
-	if !bytes.Equal(parcel.Owner, t.GetSender()) &&
-		!bytes.Equal(parcel.ProxyAccount, t.GetSender()) {
+	if !hasRole(store, txParam.Target, parcel, t.GetSender(), types.RoleDiscard) {
 		return code.TxCodePermissionDenied, "permission denied", nil
 	}
 
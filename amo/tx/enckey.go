@@ -0,0 +1,66 @@
+package tx
+
+import (
+	"crypto/elliptic"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+type EncKeyParam struct {
+	Key tmbytes.HexBytes `json:"key"` // empty to remove the key
+}
+
+func parseEncKeyParam(raw []byte) (EncKeyParam, error) {
+	var param EncKeyParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxEncKey struct {
+	TxBase
+	Param EncKeyParam `json:"-"`
+}
+
+var _ Tx = &TxEncKey{}
+
+func (t *TxEncKey) Check() (uint32, string) {
+	txParam, err := parseEncKeyParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.Key) > 0 {
+		// uncompressed P256 public key
+		x, _ := elliptic.Unmarshal(c, txParam.Key)
+		if x == nil {
+			return code.TxCodeBadParam, "improper encryption key"
+		}
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxEncKey) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	txParam := t.Param
+	if len(txParam.Key) > 0 {
+		x, _ := elliptic.Unmarshal(c, txParam.Key)
+		if x == nil {
+			return code.TxCodeBadParam, "improper encryption key", nil
+		}
+	}
+
+	s.SetEncKey(t.GetSender(), &types.EncKey{
+		Key:    txParam.Key,
+		Height: StateBlockHeight,
+	})
+
+	return code.TxCodeOK, "ok", []abci.Event{}
+}
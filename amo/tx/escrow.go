@@ -0,0 +1,202 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+func makeEscrowEvent(evType string, recipient crypto.Address,
+	target tmbytes.HexBytes, amount types.Currency) abci.Event {
+	recipientJson, _ := json.Marshal(recipient)
+	targetJson, _ := json.Marshal(target)
+	amountJson, _ := json.Marshal(amount)
+	return abci.Event{
+		Type: evType,
+		Attributes: []kv.Pair{
+			{Key: []byte("recipient"), Value: recipientJson},
+			{Key: []byte("target"), Value: targetJson},
+			{Key: []byte("amount"), Value: amountJson},
+		},
+	}
+}
+
+// confirm
+
+type ConfirmParam struct {
+	Target tmbytes.HexBytes `json:"target"`
+}
+
+func parseConfirmParam(raw []byte) (ConfirmParam, error) {
+	var param ConfirmParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxConfirm struct {
+	TxBase
+	Param ConfirmParam `json:"-"`
+}
+
+var _ Tx = &TxConfirm{}
+
+func (t *TxConfirm) Check() (uint32, string) {
+	_, err := parseConfirmParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxConfirm) Execute(store *store.Store) (uint32, string, []abci.Event) {
+	txParam := t.Param
+	recipient := t.GetSender()
+
+	escrow := store.GetEscrow(recipient, txParam.Target, false)
+	if escrow == nil {
+		return code.TxCodeEscrowNotFound, "escrow not found", nil
+	}
+
+	store.SettleEscrow(recipient, txParam.Target, escrow, types.Zero)
+
+	events := []abci.Event{
+		makeEscrowEvent("escrow_release", recipient, txParam.Target, escrow.Amount),
+	}
+
+	return code.TxCodeOK, "ok", events
+}
+
+// dispute
+
+type DisputeParam struct {
+	Target tmbytes.HexBytes `json:"target"`
+}
+
+func parseDisputeParam(raw []byte) (DisputeParam, error) {
+	var param DisputeParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxDispute struct {
+	TxBase
+	Param DisputeParam `json:"-"`
+}
+
+var _ Tx = &TxDispute{}
+
+func (t *TxDispute) Check() (uint32, string) {
+	_, err := parseDisputeParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxDispute) Execute(store *store.Store) (uint32, string, []abci.Event) {
+	txParam := t.Param
+	recipient := t.GetSender()
+
+	escrow := store.GetEscrow(recipient, txParam.Target, false)
+	if escrow == nil {
+		return code.TxCodeEscrowNotFound, "escrow not found", nil
+	}
+	if escrow.Disputed {
+		return code.TxCodeAlreadyDisputed, "escrow already disputed", nil
+	}
+	if escrow.End <= StateBlockHeight {
+		return code.TxCodeImproperTx, "hold period ended", nil
+	}
+
+	escrow.Disputed = true
+	store.SetEscrow(recipient, txParam.Target, escrow)
+
+	events := []abci.Event{
+		makeEscrowEvent("escrow_dispute", recipient, txParam.Target, escrow.Amount),
+	}
+
+	return code.TxCodeOK, "ok", events
+}
+
+// resolve
+
+type ResolveParam struct {
+	Recipient crypto.Address   `json:"recipient"`
+	Target    tmbytes.HexBytes `json:"target"`
+	Refund    types.Currency   `json:"refund"`
+}
+
+func parseResolveParam(raw []byte) (ResolveParam, error) {
+	var param ResolveParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxResolve struct {
+	TxBase
+	Param ResolveParam `json:"-"`
+}
+
+var _ Tx = &TxResolve{}
+
+func (t *TxResolve) Check() (uint32, string) {
+	txParam, err := parseResolveParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.Recipient) != crypto.AddressSize {
+		return code.TxCodeBadParam, "improper recipient address"
+	}
+	if txParam.Refund.LessThan(zero) {
+		return code.TxCodeInvalidAmount, "invalid amount"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+// Execute settles a disputed escrow by the arbiter. *Refund* of the escrow
+// goes back to the payer, and the rest goes to the payee.
+func (t *TxResolve) Execute(store *store.Store) (uint32, string, []abci.Event) {
+	txParam := t.Param
+
+	escrow := store.GetEscrow(txParam.Recipient, txParam.Target, false)
+	if escrow == nil {
+		return code.TxCodeEscrowNotFound, "escrow not found", nil
+	}
+	if !bytes.Equal(t.GetSender(), escrow.Arbiter) {
+		return code.TxCodePermissionDenied, "permission denied", nil
+	}
+	if !escrow.Disputed {
+		return code.TxCodeImproperTx, "escrow not disputed", nil
+	}
+	if txParam.Refund.GreaterThan(&escrow.Amount) {
+		return code.TxCodeInvalidAmount, "refund exceeds escrow", nil
+	}
+
+	store.SettleEscrow(txParam.Recipient, txParam.Target, escrow, &txParam.Refund)
+
+	events := []abci.Event{
+		makeEscrowEvent("escrow_refund", txParam.Recipient, txParam.Target, txParam.Refund),
+	}
+
+	return code.TxCodeOK, "ok", events
+}
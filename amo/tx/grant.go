@@ -1,7 +1,6 @@
 package tx
 
 import (
-	"bytes"
 	"encoding/binary"
 	"encoding/json"
 
@@ -18,6 +17,7 @@ type GrantParam struct {
 	Recipient crypto.Address   `json:"recipient"`
 	Target    tmbytes.HexBytes `json:"target"`
 	Custody   tmbytes.HexBytes `json:"custody"`
+	MaxAccess uint64           `json:"max_access,omitempty"`
 	Extra     json.RawMessage  `json:"extra,omitempty"`
 }
 
@@ -27,6 +27,10 @@ func parseGrantParam(raw []byte) (GrantParam, error) {
 	if err != nil {
 		return param, err
 	}
+	// access limit is available from protocol v6
+	if !protocolV6() {
+		param.MaxAccess = 0
+	}
 	return param, nil
 }
 
@@ -68,8 +72,7 @@ func (t *TxGrant) Execute(store *store.Store) (uint32, string, []abci.Event) {
 		return code.TxCodeParcelNotFound, "parcel not found", nil
 	}
 
-	if !bytes.Equal(parcel.Owner, grantor) &&
-		!bytes.Equal(parcel.ProxyAccount, grantor) {
+	if !hasRole(store, txParam.Target, parcel, grantor, types.RoleGrant) {
 		return code.TxCodePermissionDenied, "permission denied", nil
 	}
 
@@ -78,43 +81,83 @@ func (t *TxGrant) Execute(store *store.Store) (uint32, string, []abci.Event) {
 		return code.TxCodeAlreadyGranted, "parcel already granted", nil
 	}
 
+	// payment of a revoked usage may still be held for the arbiter
+	if store.GetEscrow(txParam.Recipient, txParam.Target, false) != nil {
+		return code.TxCodeAlreadyDisputed, "payment still in dispute", nil
+	}
+
 	request := store.GetRequest(txParam.Recipient, txParam.Target, false)
 	if request == nil {
 		return code.TxCodeRequestNotFound, "parcel not requested", nil
 	}
 
+	// terms of the parcel may have changed since the request
+	if rc, info := checkTerms(parcel, request.Terms); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+
 	storageID := binary.BigEndian.Uint32(txParam.Target[:types.StorageIDLen])
 	storage := store.GetStorage(storageID, false)
 	if storage == nil || storage.Active == false {
 		return code.TxCodeNoStorage, "no active storage for this parcel", nil
 	}
 
+	// payment is held in escrow during the hold period
+	hold := parcel.HoldPeriod > 0 && request.Payment.GreaterThan(zero)
+
 	balance := store.GetBalance(parcel.Owner, false)
-	if balance.Add(&request.Payment).LessThan(&storage.HostingFee) {
+	if !hold {
+		balance.Add(&request.Payment)
+	}
+	if balance.LessThan(&storage.HostingFee) {
 		return code.TxCodeNotEnoughBalance,
 			"not enough balance for hosting fee", nil
 	}
 
 	store.DeleteRequest(txParam.Recipient, txParam.Target)
 
-	store.SetUsage(txParam.Recipient, txParam.Target, &types.Usage{
+	usage = &types.Usage{
 		Custody: txParam.Custody,
 		Extra: types.Extra{
 			Register: request.Extra.Register,
 			Request:  request.Extra.Request,
 			Grant:    txParam.Extra,
 		},
-	})
+		MaxAccess: txParam.MaxAccess,
+		Terms:     request.Terms,
+	}
+	// usages are indexed by agency from protocol v6
+	if protocolV6() {
+		usage.Agency = request.Agency
+	}
+	store.SetUsage(txParam.Recipient, txParam.Target, usage)
 
-	balance = store.GetBalance(parcel.Owner, false)
-	balance.Add(&request.Payment).Sub(&storage.HostingFee)
+	balance.Sub(&storage.HostingFee)
 	store.SetBalance(parcel.Owner, balance)
+	if hold {
+		payer := request.Agency
+		if len(payer) == 0 {
+			payer = txParam.Recipient
+		}
+		store.SetEscrow(txParam.Recipient, txParam.Target, &types.Escrow{
+			Payer:   payer,
+			Payee:   parcel.Owner,
+			Arbiter: parcel.Arbiter,
+			Amount:  request.Payment,
+			End:     StateBlockHeight + parcel.HoldPeriod,
+		})
+	}
 	balance = store.GetBalance(storage.Owner, false)
 	balance.Add(&storage.HostingFee)
 	store.SetBalance(storage.Owner, balance)
 	balance = store.GetBalance(request.Dealer, false)
 	balance.Add(&request.DealerFee)
 	store.SetBalance(request.Dealer, balance)
+	if dealer := store.GetDealer(request.Dealer, false); dealer != nil {
+		dealer.Volume.Add(&request.Payment)
+		dealer.Fees.Add(&request.DealerFee)
+		store.SetDealer(request.Dealer, dealer)
+	}
 
 	return code.TxCodeOK, "ok", []abci.Event{}
 }
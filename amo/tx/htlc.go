@@ -0,0 +1,263 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+func makeHTLCEvent(id []byte, status string, preimage []byte) abci.Event {
+	idJson, _ := json.Marshal(tmbytes.HexBytes(id))
+	statusJson, _ := json.Marshal(status)
+	event := abci.Event{
+		Type: "htlc",
+		Attributes: []kv.Pair{
+			{Key: []byte("id"), Value: idJson},
+			{Key: []byte("status"), Value: statusJson},
+		},
+	}
+	if len(preimage) > 0 {
+		preimageJson, _ := json.Marshal(tmbytes.HexBytes(preimage))
+		event.Attributes = append(event.Attributes,
+			kv.Pair{Key: []byte("preimage"), Value: preimageJson})
+	}
+	return event
+}
+
+//// htlc_create
+
+type HTLCCreateParam struct {
+	Recipient crypto.Address   `json:"recipient"`
+	UDC       uint32           `json:"udc,omitempty"`
+	Amount    types.Currency   `json:"amount"`
+	HashLock  tmbytes.HexBytes `json:"hash_lock"` // sha256 of the preimage
+	Timeout   int64            `json:"timeout"`   // block height
+}
+
+func parseHTLCCreateParam(raw []byte) (HTLCCreateParam, error) {
+	var param HTLCCreateParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxHTLCCreate struct {
+	TxBase
+	Param HTLCCreateParam `json:"-"`
+}
+
+var _ Tx = &TxHTLCCreate{}
+
+func (t *TxHTLCCreate) Check() (uint32, string) {
+	txParam, err := parseHTLCCreateParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.Recipient) != crypto.AddressSize {
+		return code.TxCodeBadParam, "wrong recipient address size"
+	}
+	if bytes.Equal(t.GetSender(), txParam.Recipient) {
+		return code.TxCodeSelfTransaction, "tried to lock coins for self"
+	}
+	if !txParam.Amount.GreaterThan(zero) {
+		return code.TxCodeInvalidAmount, "invalid amount"
+	}
+	if len(txParam.HashLock) != types.HTLCHashLockSize {
+		return code.TxCodeBadParam, "wrong hash lock size"
+	}
+	if txParam.Timeout <= 0 {
+		return code.TxCodeBadParam, "invalid timeout"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxHTLCCreate) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	txParam := t.Param
+
+	if txParam.Timeout <= StateBlockHeight {
+		return code.TxCodeBadParam, "timeout already passed", nil
+	}
+	id := types.HTLCID(t.GetSender(), txParam.Recipient, txParam.HashLock)
+	if s.GetHTLC(id, false) != nil {
+		return code.TxCodeBadParam, "HTLC already exists", nil
+	}
+	if rc, info := checkUDCFrozen(s, txParam.UDC, txParam.Recipient); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	if rc, info := escrowCoin(s, txParam.UDC, t.GetSender(),
+		&txParam.Amount); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+
+	err := s.SetHTLC(id, &types.HTLC{
+		Sender:    t.GetSender(),
+		Recipient: txParam.Recipient,
+		UDC:       txParam.UDC,
+		Amount:    txParam.Amount,
+		HashLock:  txParam.HashLock,
+		Timeout:   txParam.Timeout,
+	})
+	if err != nil {
+		return code.TxCodeUnknown, err.Error(), nil
+	}
+
+	return code.TxCodeOK, "ok", []abci.Event{
+		makeHTLCEvent(id, "created", nil),
+	}
+}
+
+//// htlc_claim
+
+type HTLCClaimParam struct {
+	ID       tmbytes.HexBytes `json:"id"`
+	Preimage tmbytes.HexBytes `json:"preimage"`
+}
+
+func parseHTLCClaimParam(raw []byte) (HTLCClaimParam, error) {
+	var param HTLCClaimParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxHTLCClaim struct {
+	TxBase
+	Param HTLCClaimParam `json:"-"`
+}
+
+var _ Tx = &TxHTLCClaim{}
+
+func (t *TxHTLCClaim) Check() (uint32, string) {
+	txParam, err := parseHTLCClaimParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.ID) == 0 {
+		return code.TxCodeBadParam, "empty id"
+	}
+	if len(txParam.Preimage) == 0 {
+		return code.TxCodeBadParam, "empty preimage"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxHTLCClaim) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	txParam := t.Param
+
+	htlc := s.GetHTLC(txParam.ID, false)
+	if htlc == nil {
+		return code.TxCodeHTLCNotFound, "HTLC not found", nil
+	}
+	if !bytes.Equal(t.GetSender(), htlc.Recipient) {
+		return code.TxCodePermissionDenied, "permission denied", nil
+	}
+	if htlc.Closed() {
+		return code.TxCodeHTLCClosed, "HTLC already closed", nil
+	}
+	if StateBlockHeight >= htlc.Timeout {
+		return code.TxCodeHTLCExpired, "HTLC expired", nil
+	}
+	if !htlc.Unlocks(txParam.Preimage) {
+		return code.TxCodeBadPreimage, "preimage does not match", nil
+	}
+	if rc, info := releaseCoin(s, htlc.UDC, htlc.Recipient,
+		&htlc.Amount); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+
+	htlc.Preimage = txParam.Preimage
+	err := s.SetHTLC(txParam.ID, htlc)
+	if err != nil {
+		return code.TxCodeUnknown, err.Error(), nil
+	}
+
+	return code.TxCodeOK, "ok", []abci.Event{
+		makeHTLCEvent(txParam.ID, "claimed", txParam.Preimage),
+	}
+}
+
+//// htlc_refund
+
+type HTLCRefundParam struct {
+	ID tmbytes.HexBytes `json:"id"`
+}
+
+func parseHTLCRefundParam(raw []byte) (HTLCRefundParam, error) {
+	var param HTLCRefundParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxHTLCRefund struct {
+	TxBase
+	Param HTLCRefundParam `json:"-"`
+}
+
+var _ Tx = &TxHTLCRefund{}
+
+func (t *TxHTLCRefund) Check() (uint32, string) {
+	txParam, err := parseHTLCRefundParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.ID) == 0 {
+		return code.TxCodeBadParam, "empty id"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxHTLCRefund) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	txParam := t.Param
+
+	htlc := s.GetHTLC(txParam.ID, false)
+	if htlc == nil {
+		return code.TxCodeHTLCNotFound, "HTLC not found", nil
+	}
+	if !bytes.Equal(t.GetSender(), htlc.Sender) {
+		return code.TxCodePermissionDenied, "permission denied", nil
+	}
+	if htlc.Closed() {
+		return code.TxCodeHTLCClosed, "HTLC already closed", nil
+	}
+	if StateBlockHeight < htlc.Timeout {
+		return code.TxCodeHTLCNotExpired, "HTLC not expired yet", nil
+	}
+	refundCoin(s, htlc.UDC, htlc.Sender, &htlc.Amount)
+
+	htlc.Refunded = true
+	err := s.SetHTLC(txParam.ID, htlc)
+	if err != nil {
+		return code.TxCodeUnknown, err.Error(), nil
+	}
+
+	return code.TxCodeOK, "ok", []abci.Event{
+		makeHTLCEvent(txParam.ID, "refunded", nil),
+	}
+}
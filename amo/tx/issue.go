@@ -12,11 +12,16 @@ import (
 	"github.com/amolabs/amoabci/amo/types"
 )
 
+// When updating an existing UDC, fields left empty are kept unchanged.
 type IssueParam struct {
 	UDC       uint32           `json:"udc"`       // required
 	Desc      string           `json:"desc"`      // optional
 	Operators []crypto.Address `json:"operators"` // optional
 	Amount    types.Currency   `json:"amount"`    // required
+	// optional
+	MaxSupply *types.Currency  `json:"max_supply,omitempty"`
+	Minters   []crypto.Address `json:"minters,omitempty"`
+	Lockers   []crypto.Address `json:"lockers,omitempty"`
 }
 
 func parseIssueParam(raw []byte) (IssueParam, error) {
@@ -25,6 +30,12 @@ func parseIssueParam(raw []byte) (IssueParam, error) {
 	if err != nil {
 		return param, err
 	}
+	// supply cap and roles are available from protocol v6
+	if !protocolV6() {
+		param.MaxSupply = nil
+		param.Minters = nil
+		param.Lockers = nil
+	}
 	return param, nil
 }
 
@@ -44,6 +55,16 @@ func (t *TxIssue) Check() (uint32, string) {
 				"operator is same as the issuer"
 		}
 	}
+	for _, list := range [][]crypto.Address{param.Minters, param.Lockers} {
+		for _, addr := range list {
+			if len(addr) != crypto.AddressSize {
+				return code.TxCodeBadParam, "wrong size of address"
+			}
+		}
+	}
+	if param.MaxSupply != nil && param.MaxSupply.LessThan(zero) {
+		return code.TxCodeInvalidAmount, "invalid max supply"
+	}
 	return code.TxCodeOK, "ok"
 }
 
@@ -66,25 +87,63 @@ func (t *TxIssue) Execute(s *store.Store) (uint32, string, []abci.Event) {
 			Operators: param.Operators,
 			Desc:      param.Desc,
 			Total:     param.Amount,
+			Minters:   param.Minters,
+			Lockers:   param.Lockers,
+		}
+		if param.MaxSupply != nil {
+			udc.MaxSupply = *param.MaxSupply
+		}
+	} else if !protocolV6() {
+		// owner and operators could change everything before protocol v6
+		if !udc.IsAdmin(sender) {
+			return code.TxCodePermissionDenied, "permission denied", nil
 		}
+		udc.Operators = param.Operators
+		udc.Desc = param.Desc
+		udc.Total.Add(&param.Amount)
 	} else {
-		if bytes.Equal(sender, udc.Owner) == false {
-			match := false
-			for _, op := range udc.Operators {
-				if bytes.Equal(sender, op) {
-					match = true
-					break
-				}
+		// check roles for each change
+		if param.Amount.GreaterThan(zero) && !udc.IsMinter(sender) {
+			return code.TxCodePermissionDenied, "permission denied", nil
+		}
+		if (param.Operators != nil &&
+			!equalAddresses(param.Operators, udc.Operators)) ||
+			(param.MaxSupply != nil &&
+				!param.MaxSupply.Equals(&udc.MaxSupply)) {
+			if !bytes.Equal(sender, udc.Owner) {
+				return code.TxCodePermissionDenied, "permission denied", nil
 			}
-			if match == false {
+		}
+		if (len(param.Desc) > 0 && param.Desc != udc.Desc) ||
+			(param.Minters != nil &&
+				!equalAddresses(param.Minters, udc.Minters)) ||
+			(param.Lockers != nil &&
+				!equalAddresses(param.Lockers, udc.Lockers)) {
+			if !udc.IsAdmin(sender) {
 				return code.TxCodePermissionDenied, "permission denied", nil
 			}
 		}
 		// update fields
-		udc.Operators = param.Operators
-		udc.Desc = param.Desc
+		if param.Operators != nil {
+			udc.Operators = param.Operators
+		}
+		if len(param.Desc) > 0 {
+			udc.Desc = param.Desc
+		}
+		if param.Minters != nil {
+			udc.Minters = param.Minters
+		}
+		if param.Lockers != nil {
+			udc.Lockers = param.Lockers
+		}
+		if param.MaxSupply != nil {
+			udc.MaxSupply = *param.MaxSupply
+		}
 		udc.Total.Add(&param.Amount)
 	}
+	if udc.MaxSupply.GreaterThan(zero) && udc.Total.GreaterThan(&udc.MaxSupply) {
+		return code.TxCodeMaxSupplyExceeded, "exceeding max supply", nil
+	}
 	// update UDC balance
 	bal := s.GetUDCBalance(param.UDC, sender, false)
 	if bal == nil {
@@ -102,3 +161,15 @@ func (t *TxIssue) Execute(s *store.Store) (uint32, string, []abci.Event) {
 	}
 	return code.TxCodeOK, "ok", nil
 }
+
+func equalAddresses(a, b []crypto.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
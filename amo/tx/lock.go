@@ -1,7 +1,6 @@
 package tx
 
 import (
-	"bytes"
 	"encoding/json"
 
 	abci "github.com/tendermint/tendermint/abci/types"
@@ -16,6 +15,9 @@ type LockParam struct {
 	UDC    uint32         `json:"udc"`
 	Holder crypto.Address `json:"holder"`
 	Amount types.Currency `json:"amount"`
+	// release schedule in block height, both optional
+	Cliff int64 `json:"cliff,omitempty"`
+	End   int64 `json:"end,omitempty"`
 }
 
 func parseLockParam(raw []byte) (LockParam, error) {
@@ -24,6 +26,11 @@ func parseLockParam(raw []byte) (LockParam, error) {
 	if err != nil {
 		return param, err
 	}
+	// release schedule is available from protocol v6
+	if !protocolV6() {
+		param.Cliff = 0
+		param.End = 0
+	}
 	return param, nil
 }
 
@@ -39,6 +46,12 @@ func (t *TxLock) Check() (uint32, string) {
 	if len(param.Holder) != crypto.AddressSize {
 		return code.TxCodeBadParam, "wrong size of operator address"
 	}
+	if param.Cliff < 0 || param.End < 0 {
+		return code.TxCodeBadParam, "negative height"
+	}
+	if param.End > 0 && param.Cliff > param.End {
+		return code.TxCodeBadParam, "cliff after end"
+	}
 	return code.TxCodeOK, "ok"
 }
 
@@ -55,23 +68,37 @@ func (t *TxLock) Execute(s *store.Store) (uint32, string, []abci.Event) {
 		return code.TxCodeUDCNotFound, "UDC not found", nil
 	}
 
-	if bytes.Equal(sender, udc.Owner) == false {
-		match := false
-		for _, op := range udc.Operators {
-			if bytes.Equal(sender, op) {
-				match = true
-				break
-			}
-		}
-		if match == false {
-			return code.TxCodePermissionDenied, "permission denied", nil
-		}
+	allowed := udc.IsLocker(sender)
+	if !protocolV6() {
+		// owner and operators could lock before protocol v6
+		allowed = udc.IsAdmin(sender)
+	}
+	if !allowed {
+		return code.TxCodePermissionDenied, "permission denied", nil
+	}
+
+	lock := &types.UDCLock{
+		Amount: param.Amount,
+		Cliff:  param.Cliff,
+		End:    param.End,
+	}
+	if lock.Cliff > 0 || lock.End > 0 {
+		lock.Start = StateBlockHeight
+	}
+	if err := lock.Check(); err != nil {
+		return code.TxCodeBadParam, err.Error(), nil
 	}
 
-	err := s.SetUDCLock(param.UDC, param.Holder, &param.Amount)
+	err := s.SetUDCLockSchedule(param.UDC, param.Holder, lock)
 	if err != nil {
 		return code.TxCodeUnknown, "error setting internal db", nil
 	}
 
 	return code.TxCodeOK, "ok", nil
 }
+
+// getLockedUDC returns the amount of UDC locked in the balance of the holder
+// as of the current block height.
+func getLockedUDC(s *store.Store, udc uint32, holder crypto.Address) *types.Currency {
+	return s.GetUDCLockSchedule(udc, holder, false).Locked(StateBlockHeight)
+}
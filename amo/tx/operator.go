@@ -0,0 +1,88 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+type OperatorParam struct {
+	Target   tmbytes.HexBytes `json:"target"`
+	Operator crypto.Address   `json:"operator"`
+	Roles    uint8            `json:"roles"` // 0 to remove the operator
+}
+
+func parseOperatorParam(raw []byte) (OperatorParam, error) {
+	var param OperatorParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxOperator struct {
+	TxBase
+	Param OperatorParam `json:"-"`
+}
+
+var _ Tx = &TxOperator{}
+
+func (t *TxOperator) Check() (uint32, string) {
+	txParam, err := parseOperatorParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.Operator) != crypto.AddressSize {
+		return code.TxCodeBadParam, "improper operator address"
+	}
+	if txParam.Roles&^types.RoleAll != 0 {
+		return code.TxCodeBadParam, "improper roles"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxOperator) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	txParam := t.Param
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+
+	parcel := s.GetParcel(txParam.Target, false)
+	if parcel == nil {
+		return code.TxCodeParcelNotFound, "parcel not found", nil
+	}
+	if !bytes.Equal(parcel.Owner, t.GetSender()) {
+		return code.TxCodePermissionDenied, "permission denied", nil
+	}
+	if bytes.Equal(parcel.Owner, txParam.Operator) {
+		return code.TxCodeSelfTransaction, "owner as an operator", nil
+	}
+
+	s.SetOperator(txParam.Target, txParam.Operator, &types.Operator{
+		Roles: txParam.Roles,
+	})
+
+	return code.TxCodeOK, "ok", []abci.Event{}
+}
+
+// hasRole checks if *addr* is allowed to do an operation on a parcel
+// requiring *role*. The owner and the proxy account of the parcel are allowed
+// to do any operation.
+func hasRole(s *store.Store, parcelID []byte, parcel *types.Parcel,
+	addr crypto.Address, role uint8) bool {
+	if bytes.Equal(parcel.Owner, addr) ||
+		bytes.Equal(parcel.ProxyAccount, addr) {
+		return true
+	}
+	op := s.GetOperator(parcelID, addr, false)
+	return op != nil && op.Roles&role != 0
+}
@@ -0,0 +1,177 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+func makeOrderEvent(id uint64, status string) abci.Event {
+	idJson, _ := json.Marshal(id)
+	statusJson, _ := json.Marshal(status)
+	return abci.Event{
+		Type: "order",
+		Attributes: []kv.Pair{
+			{Key: []byte("id"), Value: idJson},
+			{Key: []byte("status"), Value: statusJson},
+		},
+	}
+}
+
+//// place_order
+
+type PlaceOrderParam struct {
+	Base   uint32         `json:"base"`
+	Quote  uint32         `json:"quote"`
+	Side   string         `json:"side"`
+	Price  types.Currency `json:"price"`  // quote coin for one whole base coin
+	Amount types.Currency `json:"amount"` // base coin
+}
+
+func parsePlaceOrderParam(raw []byte) (PlaceOrderParam, error) {
+	var param PlaceOrderParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxPlaceOrder struct {
+	TxBase
+	Param PlaceOrderParam `json:"-"`
+}
+
+var _ Tx = &TxPlaceOrder{}
+
+func (t *TxPlaceOrder) Check() (uint32, string) {
+	txParam, err := parsePlaceOrderParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if txParam.Base == txParam.Quote {
+		return code.TxCodeBadParam, "base and quote are the same"
+	}
+	if txParam.Side != types.OrderBuy && txParam.Side != types.OrderSell {
+		return code.TxCodeBadParam, "unknown side"
+	}
+	if !txParam.Price.GreaterThan(zero) {
+		return code.TxCodeInvalidAmount, "invalid price"
+	}
+	if !txParam.Amount.GreaterThan(zero) {
+		return code.TxCodeInvalidAmount, "invalid amount"
+	}
+	if !types.QuoteAmount(&txParam.Amount, &txParam.Price,
+		false).GreaterThan(zero) {
+		return code.TxCodeInvalidAmount, "order too small for the price"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxPlaceOrder) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	txParam := t.Param
+
+	order := &types.Order{
+		Owner:  t.GetSender(),
+		Base:   txParam.Base,
+		Quote:  txParam.Quote,
+		Side:   txParam.Side,
+		Price:  txParam.Price,
+		Amount: txParam.Amount,
+		Height: StateBlockHeight,
+	}
+	if order.Side == types.OrderBuy {
+		order.Escrow = *types.QuoteAmount(&txParam.Amount, &txParam.Price, true)
+	} else {
+		order.Escrow.Int.Set(&txParam.Amount.Int)
+	}
+
+	// coin to buy is credited to the owner on fills
+	buy := txParam.Base
+	if order.Side == types.OrderBuy {
+		buy = txParam.Quote
+	}
+	if buy != 0 && s.GetUDC(buy, false) == nil {
+		return code.TxCodeUDCNotFound, "UDC not found", nil
+	}
+	if rc, info := checkUDCFrozen(s, buy, order.Owner); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	if rc, info := escrowCoin(s, order.SellCoin(), order.Owner,
+		&order.Escrow); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+
+	id := s.NextOrderID()
+	err := s.SetOrder(id, order)
+	if err != nil {
+		return code.TxCodeUnknown, err.Error(), nil
+	}
+	s.MarkOrderMarket(order.Base, order.Quote)
+
+	return code.TxCodeOK, "ok", []abci.Event{
+		makeOrderEvent(id, "placed"),
+	}
+}
+
+//// cancel_order
+
+type CancelOrderParam struct {
+	ID uint64 `json:"id"`
+}
+
+func parseCancelOrderParam(raw []byte) (CancelOrderParam, error) {
+	var param CancelOrderParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxCancelOrder struct {
+	TxBase
+	Param CancelOrderParam `json:"-"`
+}
+
+var _ Tx = &TxCancelOrder{}
+
+func (t *TxCancelOrder) Check() (uint32, string) {
+	_, err := parseCancelOrderParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxCancelOrder) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	txParam := t.Param
+
+	order := s.GetOrder(txParam.ID, false)
+	if order == nil {
+		return code.TxCodeOrderNotFound, "order not found", nil
+	}
+	if !bytes.Equal(t.GetSender(), order.Owner) {
+		return code.TxCodePermissionDenied, "permission denied", nil
+	}
+	refundCoin(s, order.SellCoin(), order.Owner, &order.Escrow)
+	s.DeleteOrder(txParam.ID)
+
+	return code.TxCodeOK, "ok", []abci.Event{
+		makeOrderEvent(txParam.ID, "canceled"),
+	}
+}
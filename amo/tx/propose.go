@@ -15,6 +15,8 @@ type ProposeParam struct {
 	DraftID uint32          `json:"draft_id"`
 	Config  json.RawMessage `json:"config,omitempty"`
 	Desc    string          `json:"desc"`
+
+	StorageSlash *types.StorageSlash `json:"storage_slash,omitempty"`
 }
 
 func parseProposeParam(raw []byte) (ProposeParam, error) {
@@ -23,6 +25,10 @@ func parseProposeParam(raw []byte) (ProposeParam, error) {
 	if err != nil {
 		return param, err
 	}
+	// storage slash is available from protocol v6
+	if !protocolV6() {
+		param.StorageSlash = nil
+	}
 	return param, nil
 }
 
@@ -83,6 +89,16 @@ func (t *TxPropose) Execute(store *store.Store) (uint32, string, []abci.Event) {
 		return code.TxCodeImproperDraftConfig, err.Error(), nil
 	}
 
+	// storage slash check
+	if txParam.StorageSlash != nil {
+		if !(txParam.StorageSlash.Ratio > 0 && txParam.StorageSlash.Ratio <= 1) {
+			return code.TxCodeBadParam, "improper storage slash ratio", nil
+		}
+		if store.GetStorage(txParam.StorageSlash.Storage, false) == nil {
+			return code.TxCodeNoStorage, "no storage to slash", nil
+		}
+	}
+
 	events := []abci.Event{}
 
 	// set draft
@@ -99,6 +115,8 @@ func (t *TxPropose) Execute(store *store.Store) (uint32, string, []abci.Event) {
 		TallyQuorum:  *types.Zero,
 		TallyApprove: *types.Zero,
 		TallyReject:  *types.Zero,
+
+		StorageSlash: txParam.StorageSlash,
 	}
 	store.SetDraft(txParam.DraftID, newDraft)
 	// event
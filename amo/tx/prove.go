@@ -0,0 +1,116 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/merkle"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+type ProveParam struct {
+	Target tmbytes.HexBytes   `json:"target"`
+	Chunk  tmbytes.HexBytes   `json:"chunk"`
+	Aunts  []tmbytes.HexBytes `json:"aunts"`
+}
+
+func parseProveParam(raw []byte) (ProveParam, error) {
+	var param ProveParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxProve struct {
+	TxBase
+	Param ProveParam `json:"-"`
+}
+
+var _ Tx = &TxProve{}
+
+func (t *TxProve) Check() (uint32, string) {
+	txParam, err := parseProveParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.Target) <= types.StorageIDLen {
+		return code.TxCodeBadParam, "parcel id too short"
+	}
+	if len(txParam.Chunk) == 0 {
+		return code.TxCodeBadParam, "empty chunk"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxProve) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	txParam := t.Param
+	if len(txParam.Target) <= types.StorageIDLen {
+		return code.TxCodeBadParam, "parcel id too short", nil
+	}
+
+	parcel := s.GetParcel(txParam.Target, false)
+	if parcel == nil {
+		return code.TxCodeParcelNotFound, "parcel not found", nil
+	}
+
+	storageID := binary.BigEndian.Uint32(txParam.Target[:types.StorageIDLen])
+	storage := s.GetStorage(storageID, false)
+	if storage == nil {
+		return code.TxCodeNoStorage, "no storage for this parcel", nil
+	}
+	if !bytes.Equal(t.GetSender(), storage.Owner) {
+		return code.TxCodePermissionDenied, "permission denied", nil
+	}
+
+	challenge := s.GetChallenge(txParam.Target, false)
+	if challenge == nil {
+		return code.TxCodeChallengeNotFound, "challenge not found", nil
+	}
+
+	aunts := make([][]byte, len(txParam.Aunts))
+	for i, aunt := range txParam.Aunts {
+		aunts[i] = aunt
+	}
+	proof := merkle.SimpleProof{
+		Total:    int(challenge.ChunkCount),
+		Index:    int(challenge.Index),
+		LeafHash: tmhash.Sum(append([]byte{0}, txParam.Chunk...)),
+		Aunts:    aunts,
+	}
+	err := proof.Verify(challenge.ContentRoot, txParam.Chunk)
+	if err != nil {
+		return code.TxCodeBadProof, err.Error(), nil
+	}
+
+	s.DeleteChallenge(txParam.Target)
+
+	// the challenger loses the deposit to the storage owner
+	if challenge.Deposit.GreaterThan(zero) {
+		balance := s.GetBalance(storage.Owner, false)
+		balance.Add(&challenge.Deposit)
+		s.SetBalance(storage.Owner, balance)
+	}
+
+	parcelJson, _ := json.Marshal(txParam.Target)
+	events := []abci.Event{
+		abci.Event{
+			Type: "challenge_proven",
+			Attributes: []kv.Pair{
+				{Key: []byte("parcel"), Value: parcelJson},
+			},
+		},
+	}
+
+	return code.TxCodeOK, "ok", events
+}
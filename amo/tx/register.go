@@ -7,6 +7,7 @@ import (
 
 	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/tmhash"
 	tmbytes "github.com/tendermint/tendermint/libs/bytes"
 
 	"github.com/amolabs/amoabci/amo/code"
@@ -18,6 +19,14 @@ type RegisterParam struct {
 	Target       tmbytes.HexBytes `json:"target"`
 	Custody      tmbytes.HexBytes `json:"custody"`
 	ProxyAccount crypto.Address   `json:"proxy_account,omitempty"`
+	ContentRoot  tmbytes.HexBytes `json:"content_root,omitempty"`
+	ChunkCount   uint64           `json:"chunk_count,omitempty"`
+	Digest       tmbytes.HexBytes `json:"digest,omitempty"`
+	Size         uint64           `json:"size,omitempty"`
+	MimeType     string           `json:"mime_type,omitempty"`
+	Terms        *types.Terms     `json:"terms,omitempty"`
+	HoldPeriod   int64            `json:"hold_period,omitempty"`
+	Arbiter      crypto.Address   `json:"arbiter,omitempty"`
 	Extra        json.RawMessage  `json:"extra,omitempty"`
 }
 
@@ -27,6 +36,15 @@ func parseRegisterParam(raw []byte) (RegisterParam, error) {
 	if err != nil {
 		return param, err
 	}
+	// fields added in protocol v6 are ignored before it
+	if !protocolV6() {
+		param = RegisterParam{
+			Target:       param.Target,
+			Custody:      param.Custody,
+			ProxyAccount: param.ProxyAccount,
+			Extra:        param.Extra,
+		}
+	}
 	return param, nil
 }
 
@@ -42,11 +60,47 @@ func (t *TxRegister) Check() (uint32, string) {
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
+
+	return checkRegisterParam(txParam)
+}
+
+func checkRegisterParam(txParam RegisterParam) (uint32, string) {
 	// XXX: If len(txParam.Target) == types.StorageIDLen, then there is no room
 	// for in-storage ID for a parcel. Invalid parcel ID in that case.
 	if len(txParam.Target) <= types.StorageIDLen {
 		return code.TxCodeBadParam, "parcel id too short"
 	}
+	if len(txParam.ContentRoot) > 0 {
+		if len(txParam.ContentRoot) != tmhash.Size {
+			return code.TxCodeBadParam, "improper content root"
+		}
+		if txParam.ChunkCount == 0 {
+			return code.TxCodeBadParam, "no chunk count for content root"
+		}
+	} else if txParam.ChunkCount > 0 {
+		return code.TxCodeBadParam, "no content root for chunk count"
+	}
+	if len(txParam.Digest) > 0 {
+		if err := types.CheckMultihash(txParam.Digest); err != nil {
+			return code.TxCodeBadParam, err.Error()
+		}
+	}
+	if len(txParam.MimeType) > 0 {
+		if err := types.CheckMimeType(txParam.MimeType); err != nil {
+			return code.TxCodeBadParam, err.Error()
+		}
+	}
+	if txParam.Terms != nil {
+		if err := txParam.Terms.Check(); err != nil {
+			return code.TxCodeBadParam, err.Error()
+		}
+	}
+	if txParam.HoldPeriod < 0 {
+		return code.TxCodeBadParam, "negative hold period"
+	}
+	if txParam.HoldPeriod > 0 && len(txParam.Arbiter) != crypto.AddressSize {
+		return code.TxCodeBadParam, "improper arbiter address"
+	}
 
 	return code.TxCodeOK, "ok"
 }
@@ -65,6 +119,7 @@ func (t *TxRegister) Execute(store *store.Store) (uint32, string, []abci.Event)
 
 	sender := t.GetSender()
 	parcel := store.GetParcel(txParam.Target, false)
+	newParcel := makeParcel(sender, txParam)
 
 	if parcel == nil {
 		if store.GetBalance(sender, false).LessThan(&storage.RegistrationFee) {
@@ -78,21 +133,50 @@ func (t *TxRegister) Execute(store *store.Store) (uint32, string, []abci.Event)
 		balance.Add(&storage.RegistrationFee)
 		store.SetBalance(storage.Owner, balance)
 	} else {
-		if !bytes.Equal(sender, parcel.Owner) &&
-			!bytes.Equal(sender, parcel.ProxyAccount) {
+		if !hasRole(store, txParam.Target, parcel, sender, types.RoleEdit) {
 			return code.TxCodePermissionDenied, "permission denied", nil
 		}
+		// the sender took over the parcel before protocol v6
+		if protocolV6() {
+			updateParcel(newParcel, parcel, sender)
+		}
 	}
 
-	store.SetParcel(txParam.Target, &types.Parcel{
-		Owner:        sender,
+	store.SetParcel(txParam.Target, newParcel)
+
+	return code.TxCodeOK, "ok", []abci.Event{}
+}
+
+// updateParcel keeps the fields of a registered parcel which only the owner
+// and the proxy account can change.
+func updateParcel(newParcel, parcel *types.Parcel, sender crypto.Address) {
+	newParcel.Owner = parcel.Owner
+	if !bytes.Equal(sender, parcel.Owner) &&
+		!bytes.Equal(sender, parcel.ProxyAccount) {
+		newParcel.ProxyAccount = parcel.ProxyAccount
+		newParcel.Custody = parcel.Custody
+		newParcel.Terms = parcel.Terms
+		newParcel.HoldPeriod = parcel.HoldPeriod
+		newParcel.Arbiter = parcel.Arbiter
+	}
+}
+
+func makeParcel(owner crypto.Address, txParam RegisterParam) *types.Parcel {
+	return &types.Parcel{
+		Owner:        owner,
 		Custody:      txParam.Custody,
 		ProxyAccount: txParam.ProxyAccount,
+		ContentRoot:  txParam.ContentRoot,
+		ChunkCount:   txParam.ChunkCount,
+		Digest:       txParam.Digest,
+		Size:         txParam.Size,
+		MimeType:     txParam.MimeType,
+		Terms:        txParam.Terms,
+		HoldPeriod:   txParam.HoldPeriod,
+		Arbiter:      txParam.Arbiter,
 		Extra: types.Extra{
 			Register: txParam.Extra,
 		},
 		OnSale: true,
-	})
-
-	return code.TxCodeOK, "ok", []abci.Event{}
+	}
 }
@@ -0,0 +1,125 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+type RegisterBulkParam struct {
+	Parcels []RegisterParam `json:"parcels"`
+}
+
+func parseRegisterBulkParam(raw []byte) (RegisterBulkParam, error) {
+	var param RegisterBulkParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+// TxRegisterBulk registers multiple parcels in a storage at once. It succeeds
+// only when all of the parcels can be registered.
+type TxRegisterBulk struct {
+	TxBase
+	Param RegisterBulkParam `json:"-"`
+}
+
+var _ Tx = &TxRegisterBulk{}
+
+func (t *TxRegisterBulk) Check() (uint32, string) {
+	txParam, err := parseRegisterBulkParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.Parcels) == 0 {
+		return code.TxCodeBadParam, "no parcels"
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range txParam.Parcels {
+		rc, info := checkRegisterParam(p)
+		if rc != code.TxCodeOK {
+			return rc, info
+		}
+		if !bytes.Equal(p.Target[:types.StorageIDLen],
+			txParam.Parcels[0].Target[:types.StorageIDLen]) {
+			return code.TxCodeBadParam, "parcels in different storages"
+		}
+		if seen[string(p.Target)] {
+			return code.TxCodeBadParam, "duplicate parcel id"
+		}
+		seen[string(p.Target)] = true
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxRegisterBulk) Execute(store *store.Store) (uint32, string, []abci.Event) {
+	txParam := t.Param
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+
+	storageID := binary.BigEndian.Uint32(txParam.Parcels[0].Target[:types.StorageIDLen])
+	storage := store.GetStorage(storageID, false)
+	if storage == nil || storage.Active == false {
+		return code.TxCodeNoStorage, "no active storage for this parcel", nil
+	}
+
+	sender := t.GetSender()
+
+	// check all before registering any
+	fee := new(types.Currency).Set(0)
+	newParcels := make([]*types.Parcel, len(txParam.Parcels))
+	for i, p := range txParam.Parcels {
+		newParcels[i] = makeParcel(sender, p)
+		parcel := store.GetParcel(p.Target, false)
+		if parcel == nil {
+			fee.Add(&storage.RegistrationFee)
+			continue
+		}
+		if !hasRole(store, p.Target, parcel, sender, types.RoleEdit) {
+			return code.TxCodePermissionDenied, "permission denied", nil
+		}
+		updateParcel(newParcels[i], parcel, sender)
+	}
+
+	balance := store.GetBalance(sender, false)
+	if balance.LessThan(fee) {
+		return code.TxCodeNotEnoughBalance, "not enough balance for registration fee", nil
+	}
+	balance.Sub(fee)
+	store.SetBalance(sender, balance)
+	balance = store.GetBalance(storage.Owner, false)
+	balance.Add(fee)
+	store.SetBalance(storage.Owner, balance)
+
+	for i, p := range txParam.Parcels {
+		store.SetParcel(p.Target, newParcels[i])
+	}
+
+	storageJson, _ := json.Marshal(storageID)
+	countJson, _ := json.Marshal(len(txParam.Parcels))
+	feeJson, _ := json.Marshal(fee)
+	events := []abci.Event{
+		abci.Event{
+			Type: "register_bulk",
+			Attributes: []kv.Pair{
+				{Key: []byte("storage"), Value: storageJson},
+				{Key: []byte("count"), Value: countJson},
+				{Key: []byte("fee"), Value: feeJson},
+			},
+		},
+	}
+
+	return code.TxCodeOK, "ok", events
+}
@@ -0,0 +1,123 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+// maxReportCount bounds the accesses added to a usage by a single report.
+const maxReportCount = uint64(1000)
+
+type ReportParam struct {
+	Recipient crypto.Address   `json:"recipient"`
+	Target    tmbytes.HexBytes `json:"target"`
+	Count     uint64           `json:"count,omitempty"` // 1 if omitted
+}
+
+func parseReportParam(raw []byte) (ReportParam, error) {
+	var param ReportParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	if param.Count == 0 {
+		param.Count = 1
+	}
+	return param, nil
+}
+
+type TxReport struct {
+	TxBase
+	Param ReportParam `json:"-"`
+}
+
+var _ Tx = &TxReport{}
+
+func (t *TxReport) Check() (uint32, string) {
+	txParam, err := parseReportParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.Recipient) != crypto.AddressSize {
+		return code.TxCodeBadParam, "improper recipient address"
+	}
+	if len(txParam.Target) <= types.StorageIDLen {
+		return code.TxCodeBadParam, "parcel id too short"
+	}
+	if txParam.Count > maxReportCount {
+		return code.TxCodeBadParam, "too many accesses in a report"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+// Execute records accesses to a parcel by a recipient reported by the owner
+// of the storage which hosts the parcel. When the access count exceeds the
+// limit of the usage, the usage gets revoked while the payment held in escrow
+// is left until the hold period ends, so that the recipient can still dispute
+// the report.
+func (t *TxReport) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	txParam := t.Param
+	if len(txParam.Target) <= types.StorageIDLen {
+		return code.TxCodeBadParam, "parcel id too short", nil
+	}
+	if txParam.Count > maxReportCount {
+		return code.TxCodeBadParam, "too many accesses in a report", nil
+	}
+
+	storageID := binary.BigEndian.Uint32(txParam.Target[:types.StorageIDLen])
+	storage := s.GetStorage(storageID, false)
+	if storage == nil {
+		return code.TxCodeNoStorage, "no storage for this parcel", nil
+	}
+	if !bytes.Equal(t.GetSender(), storage.Owner) {
+		return code.TxCodePermissionDenied, "permission denied", nil
+	}
+
+	usage := s.GetUsage(txParam.Recipient, txParam.Target, false)
+	if usage == nil {
+		return code.TxCodeUsageNotFound, "usage not found", nil
+	}
+
+	if usage.AccessCount > math.MaxUint64-txParam.Count {
+		return code.TxCodeBadParam, "access count overflow", nil
+	}
+	usage.AccessCount += txParam.Count
+	usage.LastAccess = StateBlockHeight
+
+	recipientJson, _ := json.Marshal(txParam.Recipient)
+	targetJson, _ := json.Marshal(txParam.Target)
+	countJson, _ := json.Marshal(usage.AccessCount)
+	events := []abci.Event{
+		abci.Event{
+			Type: "report",
+			Attributes: []kv.Pair{
+				{Key: []byte("recipient"), Value: recipientJson},
+				{Key: []byte("target"), Value: targetJson},
+				{Key: []byte("access_count"), Value: countJson},
+			},
+		},
+	}
+
+	if usage.MaxAccess > 0 && usage.AccessCount > usage.MaxAccess {
+		// the usage is used up
+		events = append(events, revokeUsage(s, txParam.Recipient,
+			txParam.Target, usage, false))
+		return code.TxCodeOK, "ok", events
+	}
+
+	s.SetUsage(txParam.Recipient, txParam.Target, usage)
+
+	return code.TxCodeOK, "ok", events
+}
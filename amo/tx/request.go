@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/json"
+	"math/big"
 
 	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/crypto"
@@ -20,6 +21,7 @@ type RequestParam struct {
 	Recipient crypto.Address   `json:"recipient,omitempty"`
 	Dealer    crypto.Address   `json:"dealer,omitempty"`
 	DealerFee types.Currency   `json:"dealer_fee,omitempty"`
+	Terms     *types.Terms     `json:"terms,omitempty"`
 	Extra     json.RawMessage  `json:"extra,omitempty"`
 }
 
@@ -29,6 +31,10 @@ func parseRequestParam(raw []byte) (RequestParam, error) {
 	if err != nil {
 		return param, err
 	}
+	// terms are available from protocol v6
+	if !protocolV6() {
+		param.Terms = nil
+	}
 	return param, nil
 }
 
@@ -50,6 +56,12 @@ func (t *TxRequest) Check() (uint32, string) {
 		return code.TxCodeBadParam, "improper recipient address"
 	}
 
+	if txParam.Terms != nil {
+		if err := txParam.Terms.Check(); err != nil {
+			return code.TxCodeBadParam, err.Error()
+		}
+	}
+
 	return code.TxCodeOK, "ok"
 }
 
@@ -79,6 +91,7 @@ func (t *TxRequest) Execute(store *store.Store) (uint32, string, []abci.Event) {
 			Payment:   txParam.Payment,
 			Dealer:    txParam.Dealer,
 			DealerFee: txParam.DealerFee,
+			Terms:     txParam.Terms,
 			Extra: types.Extra{
 				Register: parcel.Extra.Register,
 				Request:  txParam.Extra,
@@ -99,6 +112,10 @@ func (t *TxRequest) Execute(store *store.Store) (uint32, string, []abci.Event) {
 		return code.TxCodeSelfTransaction, "requesting owned parcel", nil
 	}
 
+	if rc, info := checkTerms(parcel, request.Terms); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+
 	usage := store.GetUsage(recipient, target, false)
 	if usage != nil {
 		return code.TxCodeAlreadyGranted, "parcel already granted", nil
@@ -119,6 +136,19 @@ func (t *TxRequest) Execute(store *store.Store) (uint32, string, []abci.Event) {
 		request.DealerFee.Set(0)
 	} else if len(request.Dealer) != crypto.AddressSize {
 		return code.TxCodeBadParam, "invalid dealer address", nil
+	} else if protocolV6() {
+		// dealers need to be registered from protocol v6
+		dealer := store.GetDealer(request.Dealer, false)
+		if dealer == nil {
+			return code.TxCodeDealerNotFound, "dealer not registered", nil
+		}
+		// fee <= payment * max fee rate
+		limit := new(big.Float).SetInt(&request.Payment.Int)
+		limit.Mul(limit, new(big.Float).SetFloat64(dealer.MaxFeeRate))
+		fee := new(big.Float).SetInt(&request.DealerFee.Int)
+		if fee.Cmp(limit) > 0 {
+			return code.TxCodeInvalidAmount, "dealer fee exceeds max fee rate", nil
+		}
 	}
 
 	balance := store.GetBalance(requestor, false)
@@ -131,6 +161,10 @@ func (t *TxRequest) Execute(store *store.Store) (uint32, string, []abci.Event) {
 		return code.TxCodeNotEnoughBalance, "not enough balance", nil
 	}
 
+	if encKey := store.GetEncKey(recipient, false); encKey != nil {
+		request.RecipientKey = encKey.Key
+	}
+
 	store.SetRequest(recipient, target, &request)
 
 	balance.Sub(wanted)
@@ -138,3 +172,24 @@ func (t *TxRequest) Execute(store *store.Store) (uint32, string, []abci.Event) {
 
 	return code.TxCodeOK, "ok", []abci.Event{}
 }
+
+// checkTerms checks if the terms accepted by a request are within the terms
+// of the parcel.
+func checkTerms(parcel *types.Parcel, terms *types.Terms) (uint32, string) {
+	if parcel.Terms == nil {
+		if terms != nil {
+			return code.TxCodeTermsMismatch, "no terms for this parcel"
+		}
+		return code.TxCodeOK, "ok"
+	}
+	if terms == nil {
+		return code.TxCodeTermsMismatch, "terms not accepted"
+	}
+	if err := parcel.Terms.Accepts(terms); err != nil {
+		return code.TxCodeTermsMismatch, err.Error()
+	}
+	if terms.Expiry != 0 && terms.Expiry <= StateBlockHeight {
+		return code.TxCodeTermsMismatch, "terms already expired"
+	}
+	return code.TxCodeOK, "ok"
+}
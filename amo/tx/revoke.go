@@ -1,15 +1,16 @@
 package tx
 
 import (
-	"bytes"
 	"encoding/json"
 
 	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/crypto"
 	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/libs/kv"
 
 	"github.com/amolabs/amoabci/amo/code"
 	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
 )
 
 type RevokeParam struct {
@@ -64,8 +65,7 @@ func (t *TxRevoke) Execute(store *store.Store) (uint32, string, []abci.Event) {
 	if parcel == nil {
 		return code.TxCodeParcelNotFound, "parcel not found", nil
 	}
-	if !bytes.Equal(parcel.Owner, revoker) &&
-		!bytes.Equal(parcel.ProxyAccount, revoker) {
+	if !hasRole(store, txParam.Target, parcel, revoker, types.RoleRevoke) {
 		return code.TxCodePermissionDenied, "permission denied", nil
 	}
 
@@ -74,7 +74,32 @@ func (t *TxRevoke) Execute(store *store.Store) (uint32, string, []abci.Event) {
 		return code.TxCodeUsageNotFound, "usage not found", nil
 	}
 
-	store.DeleteUsage(txParam.Recipient, txParam.Target)
+	return code.TxCodeOK, "ok", []abci.Event{
+		revokeUsage(store, txParam.Recipient, txParam.Target, usage, true),
+	}
+}
+
+// revokeUsage removes the usage. The payment held in escrow, if any, is
+// returned to the payer when *refund* is true, and left until the hold period
+// ends otherwise. A disputed one is left for the arbiter.
+func revokeUsage(s *store.Store, recipient crypto.Address,
+	target tmbytes.HexBytes, usage *types.Usage, refund bool) abci.Event {
+	s.DeleteUsage(recipient, target)
 
-	return code.TxCodeOK, "ok", []abci.Event{}
+	escrow := s.GetEscrow(recipient, target, false)
+	if refund && escrow != nil && !escrow.Disputed {
+		s.SettleEscrow(recipient, target, escrow, &escrow.Amount)
+	}
+
+	recipientJson, _ := json.Marshal(recipient)
+	targetJson, _ := json.Marshal(target)
+	countJson, _ := json.Marshal(usage.AccessCount)
+	return abci.Event{
+		Type: "usage_revoke",
+		Attributes: []kv.Pair{
+			{Key: []byte("recipient"), Value: recipientJson},
+			{Key: []byte("target"), Value: targetJson},
+			{Key: []byte("access_count"), Value: countJson},
+		},
+	}
 }
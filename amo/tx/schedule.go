@@ -0,0 +1,197 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+const (
+	// bounds keeping the heights of the transfers far from overflowing
+	maxScheduleDelay    = int64(1000000)
+	maxScheduleInterval = int64(1000000)
+	maxScheduleCount    = uint64(1000)
+
+	maxSchedulesPerSender = uint64(100)
+)
+
+func makeScheduleEvent(id uint64, status string) abci.Event {
+	idJson, _ := json.Marshal(id)
+	statusJson, _ := json.Marshal(status)
+	return abci.Event{
+		Type: "schedule",
+		Attributes: []kv.Pair{
+			{Key: []byte("id"), Value: idJson},
+			{Key: []byte("status"), Value: statusJson},
+		},
+	}
+}
+
+//// schedule
+
+type ScheduleParam struct {
+	Recipient crypto.Address `json:"recipient"`
+	UDC       uint32         `json:"udc,omitempty"`
+	Amount    types.Currency `json:"amount"`
+	Start     int64          `json:"start"`              // height of the first transfer
+	Interval  int64          `json:"interval,omitempty"` // 0 for a single transfer
+	Count     uint64         `json:"count,omitempty"`    // 0 for a single transfer
+	Escrow    bool           `json:"escrow,omitempty"`   // false to charge at each transfer
+}
+
+func parseScheduleParam(raw []byte) (ScheduleParam, error) {
+	var param ScheduleParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	if param.Count == 0 {
+		param.Count = 1
+	}
+	return param, nil
+}
+
+type TxSchedule struct {
+	TxBase
+	Param ScheduleParam `json:"-"`
+}
+
+var _ Tx = &TxSchedule{}
+
+func (t *TxSchedule) Check() (uint32, string) {
+	txParam, err := parseScheduleParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.Recipient) != crypto.AddressSize {
+		return code.TxCodeBadParam, "wrong recipient address size"
+	}
+	if bytes.Equal(t.GetSender(), txParam.Recipient) {
+		return code.TxCodeSelfTransaction, "tried to schedule transfers to self"
+	}
+	if !txParam.Amount.GreaterThan(zero) {
+		return code.TxCodeInvalidAmount, "invalid amount"
+	}
+	if txParam.Interval < 0 || txParam.Interval > maxScheduleInterval {
+		return code.TxCodeBadParam, "invalid interval"
+	}
+	if txParam.Count > maxScheduleCount {
+		return code.TxCodeBadParam, "too many transfers"
+	}
+	if txParam.Count > 1 && txParam.Interval == 0 {
+		return code.TxCodeBadParam, "no interval for recurring transfers"
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxSchedule) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	txParam := t.Param
+
+	if txParam.Start <= StateBlockHeight {
+		return code.TxCodeBadParam, "start height already passed", nil
+	}
+	if txParam.Start-StateBlockHeight > maxScheduleDelay {
+		return code.TxCodeBadParam, "start height too far", nil
+	}
+	if s.GetScheduleCount(t.GetSender(), false) >= maxSchedulesPerSender {
+		return code.TxCodeBadParam, "too many schedules", nil
+	}
+	if txParam.UDC != 0 && s.GetUDC(txParam.UDC, false) == nil {
+		return code.TxCodeUDCNotFound, "UDC not found", nil
+	}
+
+	schedule := &types.Schedule{
+		Sender:    t.GetSender(),
+		Recipient: txParam.Recipient,
+		UDC:       txParam.UDC,
+		Amount:    txParam.Amount,
+		Interval:  txParam.Interval,
+		Count:     txParam.Count,
+		Next:      txParam.Start,
+	}
+	if txParam.Escrow {
+		total := new(types.Currency)
+		total.Int.SetUint64(txParam.Count)
+		total.Int.Mul(&total.Int, &txParam.Amount.Int)
+		if rc, info := escrowCoin(s, txParam.UDC, t.GetSender(),
+			total); rc != code.TxCodeOK {
+			return rc, info, nil
+		}
+		schedule.Escrow = total
+	}
+
+	id := s.NextScheduleID()
+	err := s.SetSchedule(id, schedule)
+	if err != nil {
+		return code.TxCodeUnknown, err.Error(), nil
+	}
+
+	return code.TxCodeOK, "ok", []abci.Event{
+		makeScheduleEvent(id, "scheduled"),
+	}
+}
+
+//// cancel_schedule
+
+type CancelScheduleParam struct {
+	ID uint64 `json:"id"`
+}
+
+func parseCancelScheduleParam(raw []byte) (CancelScheduleParam, error) {
+	var param CancelScheduleParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxCancelSchedule struct {
+	TxBase
+	Param CancelScheduleParam `json:"-"`
+}
+
+var _ Tx = &TxCancelSchedule{}
+
+func (t *TxCancelSchedule) Check() (uint32, string) {
+	_, err := parseCancelScheduleParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxCancelSchedule) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	txParam := t.Param
+
+	schedule := s.GetSchedule(txParam.ID, false)
+	if schedule == nil {
+		return code.TxCodeScheduleNotFound, "schedule not found", nil
+	}
+	if !bytes.Equal(t.GetSender(), schedule.Sender) {
+		return code.TxCodePermissionDenied, "permission denied", nil
+	}
+	if schedule.Escrow != nil {
+		refundCoin(s, schedule.UDC, schedule.Sender, schedule.Escrow)
+	}
+	s.DeleteSchedule(txParam.ID)
+
+	return code.TxCodeOK, "ok", []abci.Event{
+		makeScheduleEvent(txParam.ID, "canceled"),
+	}
+}
@@ -16,6 +16,7 @@ type SetupParam struct {
 	Url             string         `json:"url"`
 	RegistrationFee types.Currency `json:"registration_fee"`
 	HostingFee      types.Currency `json:"hosting_fee"`
+	Bond            types.Currency `json:"bond,omitempty"`
 }
 
 func parseSetupParam(raw []byte) (SetupParam, error) {
@@ -72,6 +73,13 @@ func (t *TxSetup) Execute(s *store.Store) (uint32, string, []abci.Event) {
 		sto.HostingFee = param.HostingFee
 		sto.Active = true
 	}
+	// storage bond is required from protocol v6
+	if protocolV6() {
+		rc, info := bondStorage(s, param.Storage, sto, &param.Bond)
+		if rc != code.TxCodeOK {
+			return rc, info, nil
+		}
+	}
 	// store
 	err := s.SetStorage(param.Storage, sto)
 	if err != nil {
@@ -79,3 +87,34 @@ func (t *TxSetup) Execute(s *store.Store) (uint32, string, []abci.Event) {
 	}
 	return code.TxCodeOK, "ok", nil
 }
+
+// bondStorage locks additional bond of the storage owner, if what is already
+// bonded is not enough. Bond still locked after closing the storage counts as
+// bonded.
+func bondStorage(s *store.Store, id uint32, sto *types.Storage,
+	amount *types.Currency) (uint32, string) {
+	if amount.LessThan(zero) {
+		return code.TxCodeInvalidAmount, "invalid amount"
+	}
+	bonds, heights := s.GetLockedStorageBondsWithHeight(id, false)
+	bond := new(types.Currency).Set(0)
+	bond.Add(&sto.Bond)
+	for _, locked := range bonds {
+		bond.Add(&locked.Amount)
+	}
+	bond.Add(amount)
+	if bond.LessThan(&ConfigAMOApp.StorageBond) {
+		return code.TxCodeInvalidAmount, "not enough storage bond"
+	}
+	balance := s.GetBalance(sto.Owner, false)
+	if balance.LessThan(amount) {
+		return code.TxCodeNotEnoughBalance, "not enough balance for storage bond"
+	}
+	balance.Sub(amount)
+	s.SetBalance(sto.Owner, balance)
+	for i := range bonds {
+		s.SetLockedStorageBond(id, &types.StorageBond{}, heights[i])
+	}
+	sto.Bond = *bond
+	return code.TxCodeOK, "ok"
+}
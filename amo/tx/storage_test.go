@@ -6,6 +6,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/crypto/merkle"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
 	tmdb "github.com/tendermint/tm-db"
 
 	"github.com/amolabs/amoabci/amo/code"
@@ -46,6 +49,8 @@ func TestTxSetup(t *testing.T) {
 	assert.NotNil(t, s)
 
 	storageID := uint32(1)
+	ConfigAMOApp.StorageBond = *new(types.Currency).SetAMO(10)
+	ConfigAMOApp.LockupPeriod = 2
 
 	// initial setup
 	param := SetupParam{
@@ -53,6 +58,7 @@ func TestTxSetup(t *testing.T) {
 		Url:             "http://need_to_check_url_format",
 		RegistrationFee: *new(types.Currency).SetAMO(1),
 		HostingFee:      *new(types.Currency).SetAMO(1),
+		Bond:            *new(types.Currency).SetAMO(10),
 	}
 	payload, _ := json.Marshal(param)
 	//
@@ -62,6 +68,11 @@ func TestTxSetup(t *testing.T) {
 	assert.True(t, ok)
 	rc, _ := tx.Check()
 	assert.Equal(t, code.TxCodeOK, rc)
+	// not enough balance for bond
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeNotEnoughBalance, rc)
+	// with some balance
+	s.SetBalance(makeAccAddr("provider"), new(types.Currency).SetAMO(15))
 	rc, _, _ = tx.Execute(s)
 	assert.Equal(t, code.TxCodeOK, rc)
 	// check store
@@ -72,8 +83,11 @@ func TestTxSetup(t *testing.T) {
 		Url:             "http://need_to_check_url_format",
 		RegistrationFee: *new(types.Currency).SetAMO(1),
 		HostingFee:      *new(types.Currency).SetAMO(1),
+		Bond:            *new(types.Currency).SetAMO(10),
 		Active:          true,
 	}, sto)
+	assert.Equal(t, new(types.Currency).SetAMO(5),
+		s.GetBalance(makeAccAddr("provider"), false))
 
 	// close
 	param2 := CloseParam{
@@ -97,9 +111,52 @@ func TestTxSetup(t *testing.T) {
 		Url:             "http://need_to_check_url_format",
 		RegistrationFee: *new(types.Currency).SetAMO(1),
 		HostingFee:      *new(types.Currency).SetAMO(1),
+		Bond:            *new(types.Currency).Set(0),
 		Active:          false,
 	}, sto)
+	// check locked bond
+	bond := s.GetLockedStorageBond(storageID, 2, false)
+	assert.NotNil(t, bond)
+	assert.Equal(t, makeAccAddr("provider"), bond.Owner)
+	assert.Equal(t, new(types.Currency).SetAMO(10), &bond.Amount)
+
+	// following-up setup takes back the locked bond
+	param.Bond = *new(types.Currency).Set(0)
+	payload, _ = json.Marshal(param)
+	//
+	tx = makeTestTx("setup", "provider", payload)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	sto = s.GetStorage(storageID, false)
+	assert.Equal(t, new(types.Currency).SetAMO(10), &sto.Bond)
+	assert.True(t, sto.Active)
+	assert.Nil(t, s.GetLockedStorageBond(storageID, 2, false))
+	assert.Equal(t, new(types.Currency).SetAMO(5),
+		s.GetBalance(makeAccAddr("provider"), false))
+	// close again
+	payload, _ = json.Marshal(param2)
+	rc, _, _ = makeTestTx("close", "provider", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+
+	// bond gets unlocked after lockup period
+	s.LoosenStorageBonds(false)
+	assert.Nil(t, s.GetLockedStorageBond(storageID, 2, false))
+	assert.NotNil(t, s.GetLockedStorageBond(storageID, 1, false))
+	s.LoosenStorageBonds(false)
+	assert.Nil(t, s.GetLockedStorageBond(storageID, 1, false))
+	assert.Equal(t, new(types.Currency).SetAMO(15),
+		s.GetBalance(makeAccAddr("provider"), false))
+
+	// following-up setup without enough bond
+	param.Bond = *new(types.Currency).SetAMO(5)
+	payload, _ = json.Marshal(param)
+	//
+	tx = makeTestTx("setup", "provider", payload)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeInvalidAmount, rc)
+
 	// following-up setup
+	param.Bond = *new(types.Currency).SetAMO(10)
 	payload, _ = json.Marshal(param)
 	//
 	tx = makeTestTx("setup", "provider", payload)
@@ -112,6 +169,146 @@ func TestTxSetup(t *testing.T) {
 		Url:             "http://need_to_check_url_format",
 		RegistrationFee: *new(types.Currency).SetAMO(1),
 		HostingFee:      *new(types.Currency).SetAMO(1),
+		Bond:            *new(types.Currency).SetAMO(10),
 		Active:          true,
 	}, sto)
 }
+
+func TestTxChallenge(t *testing.T) {
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	StateBlockHeight = 10
+	StateBlockHash = []byte("blockhash")
+	ConfigAMOApp.ChallengePeriod = 5
+	deposit := ConfigAMOApp.ChallengeDeposit
+	defer func() { ConfigAMOApp.ChallengeDeposit = deposit }()
+	ConfigAMOApp.ChallengeDeposit = *new(types.Currency).Set(100)
+
+	storageID := uint32(1)
+	parcelID := []byte{0x0, 0x0, 0x0, 0x1, 0xA, 0xB}
+	s.SetStorage(storageID, &types.Storage{
+		Owner:  makeAccAddr("provider"),
+		Active: true,
+	})
+
+	chunks := [][]byte{
+		[]byte("chunk0"), []byte("chunk1"), []byte("chunk2"),
+		[]byte("chunk3"), []byte("chunk4"),
+	}
+	root, proofs := merkle.SimpleProofsFromByteSlices(chunks)
+
+	// parcel without content root
+	s.SetParcel(parcelID, &types.Parcel{
+		Owner:   makeAccAddr("seller"),
+		Custody: []byte("custody"),
+	})
+	payload, _ := json.Marshal(ChallengeParam{Target: parcelID})
+	tx := makeTestTxV6("challenge", "challenger", payload)
+	_, ok := tx.(*TxChallenge)
+	assert.True(t, ok)
+	rc, _ := tx.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeImproperTx, rc)
+
+	// register with content root
+	regParam := RegisterParam{
+		Target:      parcelID,
+		Custody:     []byte("custody"),
+		ContentRoot: root,
+	}
+	payload, _ = json.Marshal(regParam)
+	tx = makeTestTx("register", "seller", payload)
+	rc, _ = tx.Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	regParam.ChunkCount = uint64(len(chunks))
+	payload, _ = json.Marshal(regParam)
+	tx = makeTestTx("register", "seller", payload)
+	rc, _ = tx.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+
+	// challenge
+	payload, _ = json.Marshal(ChallengeParam{Target: parcelID})
+	tx = makeTestTxV6("challenge", "challenger", payload)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeNotEnoughBalance, rc)
+	s.SetBalance(makeAccAddr("challenger"), new(types.Currency).Set(150))
+	rc, _, evs := tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, 1, len(evs))
+	assert.Equal(t, new(types.Currency).Set(50),
+		s.GetBalance(makeAccAddr("challenger"), false))
+	challenge := s.GetChallenge(parcelID, false)
+	assert.NotNil(t, challenge)
+	assert.Equal(t, makeAccAddr("challenger"), challenge.Challenger)
+	assert.Equal(t, int64(10), challenge.Start)
+	assert.Equal(t, int64(15), challenge.Deadline)
+	assert.Equal(t, *new(types.Currency).Set(100), challenge.Deposit)
+	assert.True(t, challenge.Index < uint64(len(chunks)))
+	// once more
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeAlreadyChallenged, rc)
+
+	index := challenge.Index
+	wrong := (index + 1) % uint64(len(chunks))
+	makeProveParam := func(i uint64) ProveParam {
+		aunts := []tmbytes.HexBytes{}
+		for _, aunt := range proofs[i].Aunts {
+			aunts = append(aunts, aunt)
+		}
+		return ProveParam{
+			Target: parcelID,
+			Chunk:  chunks[i],
+			Aunts:  aunts,
+		}
+	}
+
+	// prove by non-owner
+	payload, _ = json.Marshal(makeProveParam(index))
+	tx = makeTestTxV6("prove", "seller", payload)
+	_, ok = tx.(*TxProve)
+	assert.True(t, ok)
+	rc, _ = tx.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+	// prove with a wrong chunk
+	payload, _ = json.Marshal(makeProveParam(wrong))
+	tx = makeTestTxV6("prove", "provider", payload)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeBadProof, rc)
+	assert.NotNil(t, s.GetChallenge(parcelID, false))
+	// re-register with another content does not change the challenge
+	regParam.ContentRoot = tmhash.Sum([]byte("another root"))
+	regParam.ChunkCount = 1
+	payload, _ = json.Marshal(regParam)
+	rc, _, _ = makeTestTx("register", "seller", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	// prove
+	payload, _ = json.Marshal(makeProveParam(index))
+	tx = makeTestTxV6("prove", "provider", payload)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Nil(t, s.GetChallenge(parcelID, false))
+	// deposit forfeited to the storage owner
+	assert.Equal(t, new(types.Currency).Set(100),
+		s.GetBalance(makeAccAddr("provider"), false))
+	// no challenge to prove
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeChallengeNotFound, rc)
+
+	// challenged again only after a challenge period since the last one
+	s.SetBalance(makeAccAddr("challenger"), new(types.Currency).Set(100))
+	payload, _ = json.Marshal(ChallengeParam{Target: parcelID})
+	tx = makeTestTxV6("challenge", "challenger", payload)
+	StateBlockHeight = 19
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeAlreadyChallenged, rc)
+	StateBlockHeight = 20
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+}
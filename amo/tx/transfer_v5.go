@@ -18,11 +18,17 @@ type TransferParamV5 struct {
 	UDC    uint32           `json:"udc,omitempty"`
 	Amount types.Currency   `json:"amount,omitempty"`
 	Parcel tmbytes.HexBytes `json:"parcel,omitempty"`
+	// owner of the coins who approved the sender to spend them, available
+	// from protocol v6
+	From crypto.Address `json:"from,omitempty"`
 }
 
 func parseTransferParamV5(raw []byte) (TransferParamV5, error) {
 	var param TransferParamV5
 	err := json.Unmarshal(raw, &param)
+	if !protocolV6() {
+		param.From = nil
+	}
 	return param, err
 }
 
@@ -42,12 +48,22 @@ func (t *TxTransferV5) Check() (uint32, string) {
 	if len(txParam.To) != crypto.AddressSize {
 		return code.TxCodeBadParam, "wrong recipient address size"
 	}
-	if bytes.Equal(t.GetSender(), txParam.To) {
+	if len(txParam.From) > 0 && len(txParam.From) != crypto.AddressSize {
+		return code.TxCodeBadParam, "wrong owner address size"
+	}
+	from := t.GetSender()
+	if len(txParam.From) > 0 {
+		from = txParam.From
+	}
+	if bytes.Equal(from, txParam.To) {
 		return code.TxCodeSelfTransaction, "tried to transfer to self"
 	}
 	if len(txParam.Parcel) == 0 && txParam.Amount.Equals(zero) {
 		return code.TxCodeBadParam, "either parcel or coin should be specifed"
 	}
+	if len(txParam.Parcel) > 0 && len(txParam.From) > 0 {
+		return code.TxCodeBadParam, "parcel cannot be transferred from others"
+	}
 
 	return code.TxCodeOK, "ok"
 }
@@ -71,18 +87,38 @@ func (t *TxTransferV5) Execute(store *store.Store) (uint32, string, []abci.Event
 
 func (t *TxTransferV5) TransferCoin(store *store.Store, txParam TransferParamV5) (uint32, string, []abci.Event) {
 	udc := txParam.UDC
-	udcLock := store.GetUDCLock(udc, t.GetSender(), false)
-	fromBalance := store.GetUDCBalance(udc, t.GetSender(), false)
+	from := t.GetSender()
+	if len(txParam.From) > 0 {
+		from = txParam.From
+	}
+	if rc, info := checkUDCFrozen(store, udc, from, txParam.To); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	udcLock := getLockedUDC(store, udc, from)
+	fromBalance := store.GetUDCBalance(udc, from, false)
 	required := udcLock
 	required.Add(&txParam.Amount)
 	if fromBalance.LessThan(required) {
 		return code.TxCodeNotEnoughBalance, "not enough balance", nil
 	}
+	var allowance *types.Currency
+	if !bytes.Equal(from, t.GetSender()) {
+		var rc uint32
+		var info string
+		allowance, rc, info = checkAllowance(store, udc,
+			from, t.GetSender(), &txParam.Amount)
+		if rc != code.TxCodeOK {
+			return rc, info, nil
+		}
+	}
 	toBalance := store.GetUDCBalance(txParam.UDC, txParam.To, false)
 	fromBalance.Sub(&txParam.Amount)
 	toBalance.Add(&txParam.Amount)
-	store.SetUDCBalance(txParam.UDC, t.GetSender(), fromBalance)
+	store.SetUDCBalance(txParam.UDC, from, fromBalance)
 	store.SetUDCBalance(txParam.UDC, txParam.To, toBalance)
+	if allowance != nil {
+		store.SetUDCAllowance(udc, from, t.GetSender(), allowance)
+	}
 	return code.TxCodeOK, "ok", nil
 }
 
@@ -91,6 +127,10 @@ func (t *TxTransferV5) TransferParcel(store *store.Store, txParam TransferParamV
 	if parcel == nil {
 		return code.TxCodeParcelNotFound, "parcel not found", nil
 	}
+	if len(txParam.From) > 0 {
+		return code.TxCodeBadParam,
+			"parcel cannot be transferred from others", nil
+	}
 	sender := t.GetSender()
 	if !bytes.Equal(sender, parcel.Owner) {
 		return code.TxCodePermissionDenied, "permission denied", nil
@@ -18,10 +18,13 @@ import (
 )
 
 const (
-	defaultLockupPeriod   = int64(1000000)
-	defaultMinStakingUnit = "1000000000000000000000000"
-	defaultMaxValidators  = uint64(100)
-	defaultDraftDeposit   = "1000000000000000000000000"
+	defaultLockupPeriod     = int64(1000000)
+	defaultMinStakingUnit   = "1000000000000000000000000"
+	defaultMaxValidators    = uint64(100)
+	defaultDraftDeposit     = "1000000000000000000000000"
+	defaultStorageBond      = "100000000000000000000000"
+	defaultChallengePeriod  = int64(100)
+	defaultChallengeDeposit = "1000000000000000000"
 
 	defaultNextDraftID     = uint32(1)
 	defaultBlockHeight     = int64(1)
@@ -31,13 +34,15 @@ const (
 var (
 	// config values from the app
 	ConfigAMOApp = types.AMOAppConfig{
-		LockupPeriod:  defaultLockupPeriod,
-		MaxValidators: defaultMaxValidators,
+		LockupPeriod:    defaultLockupPeriod,
+		MaxValidators:   defaultMaxValidators,
+		ChallengePeriod: defaultChallengePeriod,
 	}
 
 	// state from the app
 	StateNextDraftID     = defaultNextDraftID
 	StateBlockHeight     = defaultBlockHeight
+	StateBlockHash       = []byte{}
 	StateProtocolVersion = defaultProtocolVersion
 
 	c    = elliptic.P256()
@@ -56,11 +61,31 @@ func init() {
 		panic(err)
 	}
 	ConfigAMOApp.DraftDeposit = *tmp
+
+	tmp, err = new(types.Currency).SetString(defaultStorageBond, 10)
+	if err != nil {
+		panic(err)
+	}
+	ConfigAMOApp.StorageBond = *tmp
+
+	tmp, err = new(types.Currency).SetString(defaultChallengeDeposit, 10)
+	if err != nil {
+		panic(err)
+	}
+	ConfigAMOApp.ChallengeDeposit = *tmp
+}
+
+// protocolV6 tells if the rules introduced in protocol version 6 apply to the
+// txs being processed.
+func protocolV6() bool {
+	return StateProtocolVersion >= 0x6
 }
 
 type Signature struct {
 	PubKey   p256.PubKeyP256  `json:"pubkey"`
 	SigBytes tmbytes.HexBytes `json:"sig_bytes"`
+	// verification method in the DID document of the sender, if any
+	KeyID string `json:"kid,omitempty"`
 }
 
 type Tx interface {
@@ -75,7 +100,7 @@ type Tx interface {
 
 	// ops
 	Sign(privKey crypto.PrivKey) error
-	Verify() bool
+	Verify(store *store.Store) bool
 	Check() (uint32, string)
 	Execute(store *store.Store) (uint32, string, []abci.Event)
 }
@@ -85,6 +110,7 @@ var _ Tx = &TxBase{}
 type TxBase struct {
 	Type       string          `json:"type"`
 	Sender     crypto.Address  `json:"sender"`
+	DID        string          `json:"did,omitempty"` // sending as a DID
 	Fee        types.Currency  `json:"fee"`
 	LastHeight string          `json:"last_height"` // num as string
 	Payload    json.RawMessage `json:"payload"`     // TODO: change to txparam
@@ -94,6 +120,7 @@ type TxBase struct {
 type TxToSign struct {
 	Type       string          `json:"type"`
 	Sender     crypto.Address  `json:"sender"`
+	DID        string          `json:"did,omitempty"` // sending as a DID
 	Fee        types.Currency  `json:"fee"`
 	LastHeight string          `json:"last_height"` // num as string
 	Payload    json.RawMessage `json:"payload"`
@@ -300,13 +327,19 @@ func (t *TxBase) Sign(privKey crypto.PrivKey) error {
 	sigJson := Signature{
 		PubKey:   p256PubKey,
 		SigBytes: sig,
+		KeyID:    t.Signature.KeyID,
 	}
 	t.Signature = sigJson
 	return nil
 }
 
-func (t *TxBase) Verify() bool {
-	if !bytes.Equal(t.Sender, t.getSignature().PubKey.Address()) {
+func (t *TxBase) Verify(s *store.Store) bool {
+	if len(t.DID) > 0 {
+		// sending as a DID is available from protocol v6
+		if !protocolV6() || !t.verifyDIDKey(s) {
+			return false
+		}
+	} else if !bytes.Equal(t.Sender, t.getSignature().PubKey.Address()) {
 		return false
 	}
 	if len(t.Signature.SigBytes) != p256.SignatureSize {
@@ -1,14 +1,18 @@
 package tx
 
 import (
+	"crypto/sha256"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/crypto/tmhash"
 	tmbytes "github.com/tendermint/tendermint/libs/bytes"
 	tmrand "github.com/tendermint/tendermint/libs/rand"
 	tmdb "github.com/tendermint/tm-db"
@@ -179,13 +183,13 @@ func TestTxSignature(t *testing.T) {
 	assert.Equal(t, _sb, string(sb))
 	err := trnx.Sign(from)
 	assert.NoError(t, err)
-	assert.True(t, trnx.Verify())
+	assert.True(t, trnx.Verify(nil))
 
 	// wrong sender address
 	trnx.Sender = to
 	err = trnx.Sign(from)
 	assert.NoError(t, err)
-	assert.False(t, trnx.Verify())
+	assert.False(t, trnx.Verify(nil))
 }
 
 func TestValidCancel(t *testing.T) {
@@ -314,6 +318,137 @@ func TestNonValidDiscard(t *testing.T) {
 	assert.Equal(t, code.TxCodePermissionDenied, rc)
 }
 
+func TestOperator(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	// target
+	tmp := make([]byte, 4)
+	binary.BigEndian.PutUint32(tmp, uint32(123))
+	parcelID := append(tmp, []byte("parcel")...)
+
+	assert.NoError(t, s.SetStorage(uint32(123), &types.Storage{
+		Owner:  makeAccAddr("provider"),
+		Url:    "http://dummy",
+		Active: true,
+	}))
+	s.SetParcel(parcelID, &types.Parcel{
+		Owner:        makeAccAddr("seller"),
+		Custody:      []byte("custody"),
+		ProxyAccount: makeAccAddr("proxy"),
+		OnSale:       true,
+	})
+
+	// improper roles
+	param := OperatorParam{
+		Target:   parcelID,
+		Operator: makeAccAddr("granter"),
+		Roles:    0x10,
+	}
+	payload, _ := json.Marshal(param)
+	rc, _ := makeTestTxV6("operator", "seller", payload).Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+
+	// add operators
+	param.Roles = types.RoleGrant
+	payload, _ = json.Marshal(param)
+	t1 := makeTestTxV6("operator", "seller", payload)
+	_, ok := t1.(*TxOperator)
+	assert.True(t, ok)
+	rc, _ = t1.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	// by other than owner
+	rc, _, _ = makeTestTxV6("operator", "proxy", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	param.Operator = makeAccAddr("editor")
+	param.Roles = types.RoleEdit | types.RoleRevoke
+	payload, _ = json.Marshal(param)
+	rc, _, _ = makeTestTxV6("operator", "seller", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	ops := s.GetOperators(parcelID, false)
+	assert.Equal(t, 2, len(ops))
+
+	// grant by granter
+	s.SetRequest(makeAccAddr("recipient"), parcelID, &types.Request{})
+	payload, _ = json.Marshal(GrantParam{
+		Recipient: makeAccAddr("recipient"),
+		Target:    parcelID,
+		Custody:   []byte("custody"),
+	})
+	rc, _, _ = makeTestTx("grant", "editor", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+	rc, _, _ = makeTestTx("grant", "granter", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+
+	// revoke by editor
+	payload, _ = json.Marshal(RevokeParam{
+		Recipient: makeAccAddr("recipient"),
+		Target:    parcelID,
+	})
+	rc, _, _ = makeTestTx("revoke", "granter", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+	rc, _, _ = makeTestTx("revoke", "editor", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+
+	// re-register by editor keeps the fields only the owner can change
+	prev := s.GetParcel(parcelID, false)
+	payload, _ = json.Marshal(RegisterParam{
+		Target:       parcelID,
+		Custody:      []byte("new custody"),
+		ProxyAccount: makeAccAddr("editor"),
+		ContentRoot:  tmhash.Sum([]byte("new root")),
+		ChunkCount:   1,
+		HoldPeriod:   10,
+		Arbiter:      makeAccAddr("editor"),
+	})
+	rc, _, _ = makeTestTx("register", "granter", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+	rc, _, _ = makeTestTx("register", "editor", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	parcel := s.GetParcel(parcelID, false)
+	assert.Equal(t, makeAccAddr("seller"), parcel.Owner)
+	assert.Equal(t, makeAccAddr("proxy"), parcel.ProxyAccount)
+	assert.Equal(t, prev.Custody, parcel.Custody)
+	assert.Equal(t, prev.HoldPeriod, parcel.HoldPeriod)
+	assert.Equal(t, prev.Arbiter, parcel.Arbiter)
+	assert.Equal(t, tmhash.Sum([]byte("new root")), []byte(parcel.ContentRoot))
+
+	// re-register by proxy changes all but the owner
+	payload, _ = json.Marshal(RegisterParam{
+		Target:       parcelID,
+		Custody:      []byte("new custody"),
+		ProxyAccount: makeAccAddr("proxy"),
+		HoldPeriod:   10,
+		Arbiter:      makeAccAddr("arbiter"),
+	})
+	rc, _, _ = makeTestTx("register", "proxy", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	parcel = s.GetParcel(parcelID, false)
+	assert.Equal(t, makeAccAddr("seller"), parcel.Owner)
+	assert.Equal(t, []byte("new custody"), []byte(parcel.Custody))
+	assert.Equal(t, int64(10), parcel.HoldPeriod)
+	assert.Equal(t, makeAccAddr("arbiter"), parcel.Arbiter)
+
+	// discard by nobody but owner and proxy
+	payload, _ = json.Marshal(DiscardParam{Target: parcelID})
+	rc, _, _ = makeTestTx("discard", "editor", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+	rc, _, _ = makeTestTx("discard", "proxy", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+
+	// remove operator
+	param.Roles = 0
+	payload, _ = json.Marshal(param)
+	rc, _, _ = makeTestTxV6("operator", "seller", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Nil(t, s.GetOperator(parcelID, makeAccAddr("editor"), false))
+	assert.Equal(t, 1, len(s.GetOperators(parcelID, false)))
+}
+
 func TestRegister(t *testing.T) {
 	// env
 	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
@@ -379,6 +514,128 @@ func TestRegister(t *testing.T) {
 	// update already registered parcel
 	rc, _, _ = t1.Execute(s)
 	assert.Equal(t, code.TxCodeOK, rc)
+
+	// content metadata
+	digest := append([]byte{0x12, 0x20}, make([]byte, 32)...) // sha2-256
+	param := RegisterParam{
+		Target:   parcelID,
+		Custody:  []byte("custody"),
+		Digest:   digest[:33],
+		Size:     1024,
+		MimeType: "text/plain; charset=utf-8",
+	}
+	payload, _ = json.Marshal(param)
+	t2 := makeTestTx("register", "seller", payload)
+	rc, _ = t2.Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	param.Digest = digest
+	param.MimeType = "text"
+	payload, _ = json.Marshal(param)
+	t2 = makeTestTx("register", "seller", payload)
+	rc, _ = t2.Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	param.MimeType = "text/plain; charset=utf-8"
+	payload, _ = json.Marshal(param)
+	t2 = makeTestTx("register", "seller", payload)
+	rc, _ = t2.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = t2.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	parcel = s.GetParcel(parcelID, false)
+	assert.NotNil(t, parcel)
+	assert.Equal(t, digest, []byte(parcel.Digest))
+	assert.Equal(t, uint64(1024), parcel.Size)
+	assert.Equal(t, "text/plain; charset=utf-8", parcel.MimeType)
+}
+
+func TestRegisterBulk(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	// targets
+	makeID := func(storageID uint32, name string) []byte {
+		tmp := make([]byte, 4)
+		binary.BigEndian.PutUint32(tmp, storageID)
+		return append(tmp, []byte(name)...)
+	}
+	param := RegisterBulkParam{
+		Parcels: []RegisterParam{
+			{Target: makeID(123, "parcel1"), Custody: []byte("custody1")},
+			{Target: makeID(123, "parcel2"), Custody: []byte("custody2")},
+			{Target: makeID(123, "parcel3"), Custody: []byte("custody3")},
+		},
+	}
+
+	// empty list
+	payload, _ := json.Marshal(RegisterBulkParam{})
+	rc, _ := makeTestTxV6("register_bulk", "seller", payload).Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	// different storages
+	bad := RegisterBulkParam{Parcels: append([]RegisterParam{
+		{Target: makeID(456, "parcel4")},
+	}, param.Parcels...)}
+	payload, _ = json.Marshal(bad)
+	rc, _ = makeTestTxV6("register_bulk", "seller", payload).Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	// duplicate targets
+	bad = RegisterBulkParam{Parcels: append([]RegisterParam{
+		param.Parcels[0],
+	}, param.Parcels...)}
+	payload, _ = json.Marshal(bad)
+	rc, _ = makeTestTxV6("register_bulk", "seller", payload).Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+
+	payload, _ = json.Marshal(param)
+	t1 := makeTestTxV6("register_bulk", "seller", payload)
+	_, ok := t1.(*TxRegisterBulk)
+	assert.True(t, ok)
+	rc, _ = t1.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+
+	// register before storage setup
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeNoStorage, rc)
+
+	assert.NoError(t, s.SetStorage(uint32(123), &types.Storage{
+		Owner:           makeAccAddr("provider"),
+		Url:             "http://dummy",
+		RegistrationFee: *new(types.Currency).SetAMO(1),
+		Active:          true,
+	}))
+
+	// one of the parcels owned by other
+	s.SetParcel(param.Parcels[2].Target, &types.Parcel{
+		Owner: makeAccAddr("other"),
+	})
+	s.SetBalance(makeAccAddr("seller"), new(types.Currency).SetAMO(2))
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+	assert.Nil(t, s.GetParcel(param.Parcels[0].Target, false))
+
+	// not enough balance for all parcels
+	s.DeleteParcel(param.Parcels[2].Target)
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeNotEnoughBalance, rc)
+	assert.Nil(t, s.GetParcel(param.Parcels[0].Target, false))
+
+	s.SetBalance(makeAccAddr("seller"), new(types.Currency).SetAMO(3))
+	rc, _, evs := t1.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, 1, len(evs))
+	for _, p := range param.Parcels {
+		parcel := s.GetParcel(p.Target, false)
+		assert.NotNil(t, parcel)
+		assert.Equal(t, []byte(p.Custody), []byte(parcel.Custody))
+	}
+	assert.Equal(t, types.Zero, s.GetBalance(makeAccAddr("seller"), false))
+	assert.Equal(t, new(types.Currency).SetAMO(3),
+		s.GetBalance(makeAccAddr("provider"), false))
+
+	// update of registered parcels is free
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
 }
 
 func TestRequest(t *testing.T) {
@@ -495,6 +752,14 @@ func TestRequest(t *testing.T) {
 	t2 := makeTestTx("request", "recipient", payload2)
 	rc, _ = t2.Check()
 	assert.Equal(t, code.TxCodeOK, rc)
+	// unregistered dealer
+	rc, _, _ = t2.Execute(s)
+	assert.Equal(t, code.TxCodeDealerNotFound, rc)
+	// dealer fee exceeding max fee rate
+	s.SetDealer(makeAccAddr("dealer"), &types.Dealer{MaxFeeRate: 1.5})
+	rc, _, _ = t2.Execute(s)
+	assert.Equal(t, code.TxCodeInvalidAmount, rc)
+	s.SetDealer(makeAccAddr("dealer"), &types.Dealer{MaxFeeRate: 2})
 	// at this point, recipient's balance is 1 AMO. not enough
 	rc, _, _ = t2.Execute(s)
 	assert.Equal(t, code.TxCodeNotEnoughBalance, rc)
@@ -510,6 +775,314 @@ func TestRequest(t *testing.T) {
 	assert.Equal(t, new(types.Currency).SetAMO(50), &req.DealerFee)
 }
 
+func TestCancelAll(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	// targets
+	tmp := make([]byte, 4)
+	binary.BigEndian.PutUint32(tmp, uint32(123))
+	parcelID1 := append(tmp, []byte("parcel1")...)
+	parcelID2 := append(tmp, []byte("parcel2")...)
+
+	payload, _ := json.Marshal(CancelAllParam{})
+	t1 := makeTestTxV6("cancel_all", "agency", payload)
+	_, ok := t1.(*TxCancelAll)
+	assert.True(t, ok)
+	rc, _ := t1.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeRequestNotFound, rc)
+
+	for _, r := range []string{"recipient1", "recipient2"} {
+		for _, p := range [][]byte{parcelID1, parcelID2} {
+			s.SetRequest(makeAccAddr(r), p, &types.Request{
+				Payment:   *new(types.Currency).SetAMO(2),
+				Agency:    makeAccAddr("agency"),
+				DealerFee: *new(types.Currency).SetAMO(1),
+			})
+		}
+	}
+
+	// for a recipient
+	payload, _ = json.Marshal(CancelAllParam{
+		Recipient: makeAccAddr("recipient1"),
+	})
+	rc, _, evs := makeTestTxV6("cancel_all", "agency", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, 1, len(evs))
+	assert.Nil(t, s.GetRequest(makeAccAddr("recipient1"), parcelID1, false))
+	assert.Nil(t, s.GetRequest(makeAccAddr("recipient1"), parcelID2, false))
+	assert.Equal(t, new(types.Currency).SetAMO(6),
+		s.GetBalance(makeAccAddr("agency"), false))
+
+	// for all
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Nil(t, s.GetRequest(makeAccAddr("recipient2"), parcelID1, false))
+	assert.Nil(t, s.GetRequest(makeAccAddr("recipient2"), parcelID2, false))
+	assert.Equal(t, new(types.Currency).SetAMO(12),
+		s.GetBalance(makeAccAddr("agency"), false))
+	requests, _ := s.GetAgencyEntries(makeAccAddr("agency"), false)
+	assert.Equal(t, 0, len(requests))
+}
+
+func TestEncKey(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+	StateBlockHeight = 3
+
+	// improper key
+	payload, _ := json.Marshal(EncKeyParam{Key: []byte("bogus key")})
+	t1 := makeTestTxV6("enckey", "recipient", payload)
+	_, ok := t1.(*TxEncKey)
+	assert.True(t, ok)
+	rc, _ := t1.Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+
+	// publish key
+	key := p256.GenPrivKeyFromSecret([]byte("enckey")).PubKey().Bytes()
+	payload, _ = json.Marshal(EncKeyParam{Key: key})
+	t1 = makeTestTxV6("enckey", "recipient", payload)
+	rc, _ = t1.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	encKey := s.GetEncKey(makeAccAddr("recipient"), false)
+	assert.NotNil(t, encKey)
+	assert.Equal(t, key, []byte(encKey.Key))
+	assert.Equal(t, int64(3), encKey.Height)
+
+	// request snapshots the key
+	tmp := make([]byte, 4)
+	binary.BigEndian.PutUint32(tmp, uint32(123))
+	parcelID := append(tmp, []byte("parcel")...)
+	assert.NoError(t, s.SetStorage(uint32(123), &types.Storage{
+		Owner:  makeAccAddr("provider"),
+		Active: true,
+	}))
+	s.SetParcel(parcelID, &types.Parcel{
+		Owner:   makeAccAddr("seller"),
+		Custody: []byte("custody"),
+	})
+	payload, _ = json.Marshal(RequestParam{Target: parcelID})
+	rc, _, _ = makeTestTx("request", "recipient", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	request := s.GetRequest(makeAccAddr("recipient"), parcelID, false)
+	assert.NotNil(t, request)
+	assert.Equal(t, key, []byte(request.RecipientKey))
+
+	// remove key
+	payload, _ = json.Marshal(EncKeyParam{})
+	rc, _, _ = makeTestTxV6("enckey", "recipient", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Nil(t, s.GetEncKey(makeAccAddr("recipient"), false))
+}
+
+func TestRequestTerms(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+	StateBlockHeight = 10
+
+	// target
+	tmp := make([]byte, 4)
+	binary.BigEndian.PutUint32(tmp, uint32(123))
+	parcelID := append(tmp, []byte("parcel")...)
+
+	assert.NoError(t, s.SetStorage(uint32(123), &types.Storage{
+		Owner:  makeAccAddr("provider"),
+		Url:    "http://dummy",
+		Active: true,
+	}))
+	s.SetParcel(parcelID, &types.Parcel{
+		Owner:   makeAccAddr("seller"),
+		Custody: []byte("custody"),
+		Terms: &types.Terms{
+			Purposes:      []string{"research", "education"},
+			Redistribute:  false,
+			Expiry:        100,
+			Jurisdictions: []string{"KR", "US"},
+		},
+	})
+	s.SetBalance(makeAccAddr("recipient"), new(types.Currency).SetAMO(1))
+
+	makeTx := func(terms *types.Terms) Tx {
+		payload, _ := json.Marshal(RequestParam{
+			Target:  parcelID,
+			Payment: *new(types.Currency).SetAMO(1),
+			Terms:   terms,
+		})
+		return makeTestTx("request", "recipient", payload)
+	}
+
+	// no terms accepted
+	rc, _, _ := makeTx(nil).Execute(s)
+	assert.Equal(t, code.TxCodeTermsMismatch, rc)
+	// improper terms
+	rc, _ = makeTx(&types.Terms{Purposes: []string{""}}).Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	// purpose not allowed
+	rc, _, _ = makeTx(&types.Terms{
+		Purposes: []string{"commercial"},
+		Expiry:   50,
+	}).Execute(s)
+	assert.Equal(t, code.TxCodeTermsMismatch, rc)
+	// no purpose stated
+	rc, _, _ = makeTx(&types.Terms{
+		Expiry: 50,
+	}).Execute(s)
+	assert.Equal(t, code.TxCodeTermsMismatch, rc)
+	// redistribution not allowed
+	rc, _, _ = makeTx(&types.Terms{
+		Purposes:     []string{"research"},
+		Redistribute: true,
+		Expiry:       50,
+	}).Execute(s)
+	assert.Equal(t, code.TxCodeTermsMismatch, rc)
+	// beyond expiry
+	rc, _, _ = makeTx(&types.Terms{
+		Purposes: []string{"research"},
+	}).Execute(s)
+	assert.Equal(t, code.TxCodeTermsMismatch, rc)
+	rc, _, _ = makeTx(&types.Terms{
+		Purposes: []string{"research"},
+		Expiry:   200,
+	}).Execute(s)
+	assert.Equal(t, code.TxCodeTermsMismatch, rc)
+	// already expired
+	rc, _, _ = makeTx(&types.Terms{
+		Purposes: []string{"research"},
+		Expiry:   5,
+	}).Execute(s)
+	assert.Equal(t, code.TxCodeTermsMismatch, rc)
+	// jurisdiction not allowed
+	rc, _, _ = makeTx(&types.Terms{
+		Purposes:      []string{"research"},
+		Expiry:        50,
+		Jurisdictions: []string{"EU"},
+	}).Execute(s)
+	assert.Equal(t, code.TxCodeTermsMismatch, rc)
+
+	// matching subset
+	terms := &types.Terms{
+		Purposes:      []string{"research"},
+		Expiry:        50,
+		Jurisdictions: []string{"KR"},
+	}
+	rc, _, _ = makeTx(terms).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	request := s.GetRequest(makeAccAddr("recipient"), parcelID, false)
+	assert.NotNil(t, request)
+	assert.Equal(t, terms, request.Terms)
+
+	// terms of the parcel narrowed before grant
+	parcel := s.GetParcel(parcelID, false)
+	parcel.Terms.Purposes = []string{"education"}
+	s.SetParcel(parcelID, parcel)
+	payload, _ := json.Marshal(GrantParam{
+		Recipient: makeAccAddr("recipient"),
+		Target:    parcelID,
+		Custody:   []byte("custody"),
+	})
+	rc, _, _ = makeTestTx("grant", "seller", payload).Execute(s)
+	assert.Equal(t, code.TxCodeTermsMismatch, rc)
+	parcel.Terms.Purposes = []string{"research", "education"}
+	s.SetParcel(parcelID, parcel)
+
+	// terms carried over to usage
+	rc, _, _ = makeTestTx("grant", "seller", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	usage := s.GetUsage(makeAccAddr("recipient"), parcelID, false)
+	assert.NotNil(t, usage)
+	assert.Equal(t, terms, usage.Terms)
+
+	// terms for parcel without terms
+	s.SetParcel(parcelID, &types.Parcel{
+		Owner:   makeAccAddr("seller"),
+		Custody: []byte("custody"),
+	})
+	s.DeleteUsage(makeAccAddr("recipient"), parcelID)
+	rc, _, _ = makeTx(terms).Execute(s)
+	assert.Equal(t, code.TxCodeTermsMismatch, rc)
+}
+
+func TestDealer(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	// register dealer
+	payload, _ := json.Marshal(DealerParam{MaxFeeRate: -0.1})
+	rc, _ := makeTestTxV6("dealer", "dealer", payload).Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	payload, _ = json.Marshal(DealerParam{MaxFeeRate: 1.1})
+	rc, _ = makeTestTxV6("dealer", "dealer", payload).Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	assert.False(t, validFeeRate(math.NaN()))
+	assert.False(t, validFeeRate(math.Inf(1)))
+	payload, _ = json.Marshal(DealerParam{
+		MaxFeeRate: 0.1,
+		Metadata:   []byte(`{"name":"dealer"}`),
+	})
+	t1 := makeTestTxV6("dealer", "dealer", payload)
+	_, ok := t1.(*TxDealer)
+	assert.True(t, ok)
+	rc, _ = t1.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	dealer := s.GetDealer(makeAccAddr("dealer"), false)
+	assert.NotNil(t, dealer)
+	assert.Equal(t, 0.1, dealer.MaxFeeRate)
+
+	// target
+	tmp := make([]byte, 4)
+	binary.BigEndian.PutUint32(tmp, uint32(123))
+	parcelID := append(tmp, []byte("parcel")...)
+	assert.NoError(t, s.SetStorage(uint32(123), &types.Storage{
+		Owner:  makeAccAddr("provider"),
+		Active: true,
+	}))
+	s.SetParcel(parcelID, &types.Parcel{
+		Owner:   makeAccAddr("seller"),
+		Custody: []byte("custody"),
+	})
+	s.SetBalance(makeAccAddr("recipient"), new(types.Currency).SetAMO(11))
+
+	// request and grant through the dealer
+	payload, _ = json.Marshal(RequestParam{
+		Target:    parcelID,
+		Payment:   *new(types.Currency).SetAMO(10),
+		Dealer:    makeAccAddr("dealer"),
+		DealerFee: *new(types.Currency).SetAMO(1),
+	})
+	rc, _, _ = makeTestTx("request", "recipient", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	payload, _ = json.Marshal(GrantParam{
+		Recipient: makeAccAddr("recipient"),
+		Target:    parcelID,
+		Custody:   []byte("custody"),
+	})
+	rc, _, _ = makeTestTx("grant", "seller", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+
+	// update keeps statistics
+	payload, _ = json.Marshal(DealerParam{MaxFeeRate: 0.2})
+	rc, _, _ = makeTestTxV6("dealer", "dealer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	dealer = s.GetDealer(makeAccAddr("dealer"), false)
+	assert.Equal(t, 0.2, dealer.MaxFeeRate)
+	assert.Equal(t, new(types.Currency).SetAMO(10), &dealer.Volume)
+	assert.Equal(t, new(types.Currency).SetAMO(1), &dealer.Fees)
+}
+
 func TestGrant(t *testing.T) {
 	// env
 	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
@@ -608,6 +1181,267 @@ func TestGrant(t *testing.T) {
 	assert.Equal(t, []byte(`"any json for grant"`), []byte(usage.Extra.Grant))
 }
 
+func TestReport(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+	StateBlockHeight = 7
+
+	// target
+	tmp := make([]byte, 4)
+	binary.BigEndian.PutUint32(tmp, uint32(123))
+	parcelID := append(tmp, []byte("parcel")...)
+
+	payload, _ := json.Marshal(ReportParam{
+		Recipient: makeAccAddr("recipient"),
+		Target:    parcelID,
+	})
+	t1 := makeTestTxV6("report", "provider", payload)
+	_, ok := t1.(*TxReport)
+	assert.True(t, ok)
+	rc, _ := t1.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+
+	// report before storage setup
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeNoStorage, rc)
+
+	assert.NoError(t, s.SetStorage(uint32(123), &types.Storage{
+		Owner:  makeAccAddr("provider"),
+		Url:    "http://dummy",
+		Active: true,
+	}))
+
+	// report without permission
+	t2 := makeTestTxV6("report", "seller", payload)
+	rc, _, _ = t2.Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+
+	// report for non-existent usage
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeUsageNotFound, rc)
+
+	s.SetUsage(makeAccAddr("recipient"), parcelID, &types.Usage{
+		Custody:   []byte("custody"),
+		MaxAccess: 3,
+	})
+	s.SetEscrow(makeAccAddr("recipient"), parcelID, &types.Escrow{
+		Payer:  makeAccAddr("recipient"),
+		Payee:  makeAccAddr("seller"),
+		Amount: *new(types.Currency).Set(100),
+		End:    100,
+	})
+	rc, _, evs := t1.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, 1, len(evs))
+	usage := s.GetUsage(makeAccAddr("recipient"), parcelID, false)
+	assert.Equal(t, uint64(1), usage.AccessCount)
+	assert.Equal(t, int64(7), usage.LastAccess)
+
+	// reaching the limit
+	payload, _ = json.Marshal(ReportParam{
+		Recipient: makeAccAddr("recipient"),
+		Target:    parcelID,
+		Count:     2,
+	})
+	t3 := makeTestTxV6("report", "provider", payload)
+	rc, _, evs = t3.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, 1, len(evs))
+	usage = s.GetUsage(makeAccAddr("recipient"), parcelID, false)
+	assert.Equal(t, uint64(3), usage.AccessCount)
+
+	// too many accesses at once
+	payload, _ = json.Marshal(ReportParam{
+		Recipient: makeAccAddr("recipient"),
+		Target:    parcelID,
+		Count:     math.MaxUint64,
+	})
+	rc, _ = makeTestTxV6("report", "provider", payload).Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	rc, _, _ = makeTestTxV6("report", "provider", payload).Execute(s)
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	usage = s.GetUsage(makeAccAddr("recipient"), parcelID, false)
+	assert.Equal(t, uint64(3), usage.AccessCount)
+
+	// exceeding the limit
+	rc, _, evs = t1.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, 2, len(evs))
+	assert.Equal(t, "usage_revoke", evs[1].Type)
+	assert.Equal(t, []byte("4"), evs[1].Attributes[2].Value)
+	assert.Nil(t, s.GetUsage(makeAccAddr("recipient"), parcelID, false))
+	// payment held is left until the hold period ends
+	assert.NotNil(t, s.GetEscrow(makeAccAddr("recipient"), parcelID, false))
+	assert.Equal(t, types.Zero, s.GetBalance(makeAccAddr("seller"), false))
+	s.ReleaseEscrows(99, false)
+	assert.NotNil(t, s.GetEscrow(makeAccAddr("recipient"), parcelID, false))
+	s.ReleaseEscrows(100, false)
+	assert.Nil(t, s.GetEscrow(makeAccAddr("recipient"), parcelID, false))
+	assert.Equal(t, new(types.Currency).Set(100),
+		s.GetBalance(makeAccAddr("seller"), false))
+}
+
+func TestEscrow(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+	StateBlockHeight = 10
+
+	// target
+	tmp := make([]byte, 4)
+	binary.BigEndian.PutUint32(tmp, uint32(123))
+	parcelID := append(tmp, []byte("parcel")...)
+
+	assert.NoError(t, s.SetStorage(uint32(123), &types.Storage{
+		Owner:  makeAccAddr("provider"),
+		Url:    "http://dummy",
+		Active: true,
+	}))
+
+	// register with hold period but without arbiter
+	param := RegisterParam{
+		Target:     parcelID,
+		Custody:    []byte("custody"),
+		HoldPeriod: 5,
+	}
+	payload, _ := json.Marshal(param)
+	rc, _ := makeTestTx("register", "seller", payload).Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	param.Arbiter = makeAccAddr("arbiter")
+	payload, _ = json.Marshal(param)
+	tx := makeTestTx("register", "seller", payload)
+	rc, _ = tx.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+
+	request := func(buyer string) {
+		s.SetRequest(makeAccAddr(buyer), parcelID, &types.Request{
+			Payment: *new(types.Currency).SetAMO(10),
+		})
+		payload, _ := json.Marshal(GrantParam{
+			Recipient: makeAccAddr(buyer),
+			Target:    parcelID,
+			Custody:   []byte("custody"),
+		})
+		rc, _, _ := makeTestTx("grant", "seller", payload).Execute(s)
+		assert.Equal(t, code.TxCodeOK, rc)
+	}
+	payload, _ = json.Marshal(ConfirmParam{Target: parcelID})
+
+	// grant holds payment in escrow
+	request("buyer1")
+	assert.Equal(t, types.Zero, s.GetBalance(makeAccAddr("seller"), false))
+	escrow := s.GetEscrow(makeAccAddr("buyer1"), parcelID, false)
+	assert.NotNil(t, escrow)
+	assert.Equal(t, makeAccAddr("buyer1"), escrow.Payer)
+	assert.Equal(t, makeAccAddr("seller"), escrow.Payee)
+	assert.Equal(t, int64(15), escrow.End)
+	// confirm by other than recipient
+	rc, _, _ = makeTestTxV6("confirm", "buyer2", payload).Execute(s)
+	assert.Equal(t, code.TxCodeEscrowNotFound, rc)
+	// confirm
+	tx = makeTestTxV6("confirm", "buyer1", payload)
+	_, ok := tx.(*TxConfirm)
+	assert.True(t, ok)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Nil(t, s.GetEscrow(makeAccAddr("buyer1"), parcelID, false))
+	assert.Equal(t, new(types.Currency).SetAMO(10),
+		s.GetBalance(makeAccAddr("seller"), false))
+
+	// hold expires
+	request("buyer2")
+	s.ReleaseEscrows(14, false)
+	assert.NotNil(t, s.GetEscrow(makeAccAddr("buyer2"), parcelID, false))
+	s.ReleaseEscrows(15, false)
+	assert.Nil(t, s.GetEscrow(makeAccAddr("buyer2"), parcelID, false))
+	assert.Equal(t, new(types.Currency).SetAMO(20),
+		s.GetBalance(makeAccAddr("seller"), false))
+
+	// dispute and resolve
+	request("buyer3")
+	tx = makeTestTxV6("dispute", "buyer3", payload)
+	_, ok = tx.(*TxDispute)
+	assert.True(t, ok)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeAlreadyDisputed, rc)
+	// disputed escrow is not released
+	s.ReleaseEscrows(15, false)
+	assert.NotNil(t, s.GetEscrow(makeAccAddr("buyer3"), parcelID, false))
+
+	resolve := ResolveParam{
+		Recipient: makeAccAddr("buyer3"),
+		Target:    parcelID,
+		Refund:    *new(types.Currency).SetAMO(11),
+	}
+	payload, _ = json.Marshal(resolve)
+	rc, _, _ = makeTestTxV6("resolve", "seller", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+	rc, _, _ = makeTestTxV6("resolve", "arbiter", payload).Execute(s)
+	assert.Equal(t, code.TxCodeInvalidAmount, rc)
+	resolve.Refund = *new(types.Currency).SetAMO(4)
+	payload, _ = json.Marshal(resolve)
+	tx = makeTestTxV6("resolve", "arbiter", payload)
+	_, ok = tx.(*TxResolve)
+	assert.True(t, ok)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Nil(t, s.GetEscrow(makeAccAddr("buyer3"), parcelID, false))
+	assert.Equal(t, new(types.Currency).SetAMO(4),
+		s.GetBalance(makeAccAddr("buyer3"), false))
+	assert.Equal(t, new(types.Currency).SetAMO(26),
+		s.GetBalance(makeAccAddr("seller"), false))
+
+	// dispute after hold period
+	request("buyer4")
+	StateBlockHeight = 15
+	payload, _ = json.Marshal(DisputeParam{Target: parcelID})
+	rc, _, _ = makeTestTxV6("dispute", "buyer4", payload).Execute(s)
+	assert.Equal(t, code.TxCodeImproperTx, rc)
+
+	// revoke during hold period refunds payment
+	payload, _ = json.Marshal(RevokeParam{
+		Recipient: makeAccAddr("buyer4"),
+		Target:    parcelID,
+	})
+	rc, _, _ = makeTestTx("revoke", "seller", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Nil(t, s.GetEscrow(makeAccAddr("buyer4"), parcelID, false))
+	assert.Equal(t, new(types.Currency).SetAMO(10),
+		s.GetBalance(makeAccAddr("buyer4"), false))
+
+	// disputed escrow survives revoke and blocks another grant
+	request("buyer5")
+	payload, _ = json.Marshal(DisputeParam{Target: parcelID})
+	rc, _, _ = makeTestTxV6("dispute", "buyer5", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	payload, _ = json.Marshal(RevokeParam{
+		Recipient: makeAccAddr("buyer5"),
+		Target:    parcelID,
+	})
+	rc, _, _ = makeTestTx("revoke", "seller", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	s.SetRequest(makeAccAddr("buyer5"), parcelID, &types.Request{
+		Payment: *new(types.Currency).SetAMO(10),
+	})
+	payload, _ = json.Marshal(GrantParam{
+		Recipient: makeAccAddr("buyer5"),
+		Target:    parcelID,
+		Custody:   []byte("custody"),
+	})
+	rc, _, _ = makeTestTx("grant", "seller", payload).Execute(s)
+	assert.Equal(t, code.TxCodeAlreadyDisputed, rc)
+	escrow = s.GetEscrow(makeAccAddr("buyer5"), parcelID, false)
+	assert.NotNil(t, escrow)
+	assert.True(t, escrow.Disputed)
+}
+
 func TestValidRevoke(t *testing.T) {
 	// env
 	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
@@ -1243,6 +2077,7 @@ func TestPropose(t *testing.T) {
 		DraftQuorumRate:    float64(0.1),
 		DraftPassRate:      float64(0.7),
 		DraftRefundRate:    float64(0.2),
+		ChallengePeriod:    int64(100),
 	}
 
 	// target
@@ -1402,6 +2237,7 @@ func TestVote(t *testing.T) {
 		DraftQuorumRate:    float64(0.1),
 		DraftPassRate:      float64(0.7),
 		DraftRefundRate:    float64(0.2),
+		ChallengePeriod:    int64(100),
 	}
 
 	StateNextDraftID = uint32(1)
@@ -1477,3 +2313,406 @@ func TestVote(t *testing.T) {
 	rc, _, _ = t3.Execute(s)
 	assert.Equal(t, code.TxCodeVoteNotOpen, rc)
 }
+
+func TestHTLC(t *testing.T) {
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	StateBlockHeight = 100
+	defer func() { StateBlockHeight = defaultBlockHeight }()
+
+	sender := makeAccAddr("sender")
+	recipient := makeAccAddr("recipient")
+	s.SetUDC(123, &types.UDC{
+		Owner: makeAccAddr("issuer"),
+		Total: *new(types.Currency).Set(1000),
+	})
+	s.SetUDCBalance(123, sender, new(types.Currency).Set(1000))
+	s.SetUDCLock(123, sender, new(types.Currency).Set(400))
+	s.SetBalance(sender, new(types.Currency).Set(100))
+
+	preimage := []byte("secret")
+	hashLock := sha256.Sum256(preimage)
+	create := func(udc uint32, amount uint64, timeout int64) (uint32, []abci.Event) {
+		payload, _ := json.Marshal(HTLCCreateParam{
+			Recipient: recipient,
+			UDC:       udc,
+			Amount:    *new(types.Currency).Set(amount),
+			HashLock:  hashLock[:],
+			Timeout:   timeout,
+		})
+		rc, _, events := makeTestTxV6("htlc_create", "sender", payload).Execute(s)
+		return rc, events
+	}
+
+	// check
+	payload, _ := json.Marshal(HTLCCreateParam{
+		Recipient: recipient,
+		Amount:    *new(types.Currency).Set(10),
+		HashLock:  preimage,
+		Timeout:   200,
+	})
+	tx := makeTestTxV6("htlc_create", "sender", payload)
+	_, ok := tx.(*TxHTLCCreate)
+	assert.True(t, ok)
+	rc, _ := tx.Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	rc, _ = create(0, 0, 200)
+	assert.Equal(t, code.TxCodeInvalidAmount, rc)
+	rc, _ = create(0, 10, 100)
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	rc, _ = create(124, 10, 200)
+	assert.Equal(t, code.TxCodeUDCNotFound, rc)
+	// locked coins cannot be escrowed
+	rc, _ = create(123, 700, 200)
+	assert.Equal(t, code.TxCodeNotEnoughBalance, rc)
+
+	// create
+	rc, events := create(123, 600, 200)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, "htlc", events[0].Type)
+	assert.Equal(t, new(types.Currency).Set(400),
+		s.GetUDCBalance(123, sender, false))
+	id := types.HTLCID(sender, recipient, hashLock[:])
+	htlc := s.GetHTLC(id, false)
+	assert.NotNil(t, htlc)
+	assert.Equal(t, int64(200), htlc.Timeout)
+	rc, _ = create(123, 100, 200)
+	assert.Equal(t, code.TxCodeBadParam, rc)
+
+	claim := func(sender string, preimage []byte) uint32 {
+		payload, _ := json.Marshal(HTLCClaimParam{ID: id, Preimage: preimage})
+		rc, _, _ := makeTestTxV6("htlc_claim", sender, payload).Execute(s)
+		return rc
+	}
+	refund := func(sender string) uint32 {
+		payload, _ := json.Marshal(HTLCRefundParam{ID: id})
+		rc, _, _ := makeTestTxV6("htlc_refund", sender, payload).Execute(s)
+		return rc
+	}
+
+	// claim
+	assert.Equal(t, code.TxCodeHTLCNotExpired, refund("sender"))
+	assert.Equal(t, code.TxCodePermissionDenied, claim("sender", preimage))
+	assert.Equal(t, code.TxCodeBadPreimage, claim("recipient", []byte("guess")))
+	assert.Equal(t, code.TxCodeOK, claim("recipient", preimage))
+	assert.Equal(t, new(types.Currency).Set(600),
+		s.GetUDCBalance(123, recipient, false))
+	assert.Equal(t, tmbytes.HexBytes(preimage), s.GetHTLC(id, false).Preimage)
+	assert.Equal(t, code.TxCodeHTLCClosed, claim("recipient", preimage))
+	StateBlockHeight = 200
+	assert.Equal(t, code.TxCodeHTLCClosed, refund("sender"))
+
+	// refund of AMO
+	hashLock = sha256.Sum256([]byte("another secret"))
+	id = types.HTLCID(sender, recipient, hashLock[:])
+	rc, _ = create(0, 100, 300)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, new(types.Currency).Set(0), s.GetBalance(sender, false))
+	StateBlockHeight = 300
+	assert.Equal(t, code.TxCodeHTLCExpired,
+		claim("recipient", []byte("another secret")))
+	assert.Equal(t, code.TxCodePermissionDenied, refund("recipient"))
+	assert.Equal(t, code.TxCodeOK, refund("sender"))
+	assert.Equal(t, new(types.Currency).Set(100), s.GetBalance(sender, false))
+	assert.True(t, s.GetHTLC(id, false).Refunded)
+	assert.Equal(t, code.TxCodeHTLCClosed, refund("sender"))
+
+	// payout to frozen recipient is blocked, while refund is not
+	hashLock = sha256.Sum256([]byte("frozen secret"))
+	id = types.HTLCID(sender, recipient, hashLock[:])
+	s.SetUDCLock(123, sender, new(types.Currency).Set(0))
+	rc, _ = create(123, 100, 400)
+	assert.Equal(t, code.TxCodeOK, rc)
+	s.SetUDCFrozen(123, recipient, true)
+	assert.Equal(t, code.TxCodeFrozen,
+		claim("recipient", []byte("frozen secret")))
+	s.SetUDCFrozen(123, sender, true)
+	StateBlockHeight = 400
+	assert.Equal(t, code.TxCodeOK, refund("sender"))
+	assert.Equal(t, new(types.Currency).Set(400),
+		s.GetUDCBalance(123, sender, false))
+}
+
+func TestOrder(t *testing.T) {
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	owner := makeAccAddr("owner")
+	s.SetUDC(123, &types.UDC{
+		Owner: makeAccAddr("issuer"),
+		Total: *new(types.Currency).Set(1000),
+	})
+	s.SetUDCBalance(123, owner, new(types.Currency).Set(1000))
+	s.SetBalance(owner, new(types.Currency).SetAMO(10))
+
+	place := func(param PlaceOrderParam) (uint32, []abci.Event) {
+		payload, _ := json.Marshal(param)
+		rc, _, events := makeTestTxV6("place_order", "owner", payload).Execute(s)
+		return rc, events
+	}
+
+	// check
+	param := PlaceOrderParam{
+		Base:   123,
+		Quote:  123,
+		Side:   types.OrderSell,
+		Price:  *new(types.Currency).SetAMO(2),
+		Amount: *new(types.Currency).Set(100),
+	}
+	payload, _ := json.Marshal(param)
+	tx := makeTestTxV6("place_order", "owner", payload)
+	_, ok := tx.(*TxPlaceOrder)
+	assert.True(t, ok)
+	rc, _ := tx.Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	param.Quote = 0
+	param.Side = "bid"
+	rc, _ = place(param)
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	param.Side = types.OrderSell
+	param.Price = *new(types.Currency)
+	rc, _ = place(param)
+	assert.Equal(t, code.TxCodeInvalidAmount, rc)
+	// dust order pays nothing at the price
+	param.Price = *new(types.Currency).Set(1)
+	rc, _ = place(param)
+	assert.Equal(t, code.TxCodeInvalidAmount, rc)
+	param.Price = *new(types.Currency).SetAMO(2)
+	param.Base = 124
+	rc, _ = place(param)
+	assert.Equal(t, code.TxCodeUDCNotFound, rc)
+	param.Base = 123
+	param.Amount = *new(types.Currency).Set(1001)
+	rc, _ = place(param)
+	assert.Equal(t, code.TxCodeNotEnoughBalance, rc)
+
+	// sell order escrows the base coin
+	param.Amount = *new(types.Currency).Set(100)
+	rc, events := place(param)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, "order", events[0].Type)
+	assert.Equal(t, new(types.Currency).Set(900),
+		s.GetUDCBalance(123, owner, false))
+	// buy order escrows the quote coin
+	param.Side = types.OrderBuy
+	param.Price = *new(types.Currency).SetAMO(0.5)
+	rc, _ = place(param)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, new(types.Currency).Set(uint64(10)*types.OneAMOUint64-50),
+		s.GetBalance(owner, false))
+	orders, _ := s.GetOrdersByOwner(owner, 0, 10, false)
+	assert.Equal(t, 2, len(orders))
+
+	// cancel
+	cancel := func(sender string, id uint64) uint32 {
+		payload, _ := json.Marshal(CancelOrderParam{ID: id})
+		rc, _, _ := makeTestTxV6("cancel_order", sender, payload).Execute(s)
+		return rc
+	}
+	assert.Equal(t, code.TxCodeOrderNotFound, cancel("owner", 3))
+	assert.Equal(t, code.TxCodePermissionDenied, cancel("other", 1))
+	assert.Equal(t, code.TxCodeOK, cancel("owner", 1))
+	assert.Equal(t, code.TxCodeOK, cancel("owner", 2))
+	assert.Equal(t, new(types.Currency).Set(1000),
+		s.GetUDCBalance(123, owner, false))
+	assert.Equal(t, new(types.Currency).SetAMO(10), s.GetBalance(owner, false))
+	assert.Equal(t, code.TxCodeOrderNotFound, cancel("owner", 1))
+}
+
+func TestSchedule(t *testing.T) {
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	StateBlockHeight = 100
+	defer func() { StateBlockHeight = defaultBlockHeight }()
+
+	sender := makeAccAddr("sender")
+	recipient := makeAccAddr("recipient")
+	s.SetBalance(sender, new(types.Currency).Set(1000))
+
+	schedule := func(param ScheduleParam) (uint32, []abci.Event) {
+		payload, _ := json.Marshal(param)
+		rc, _, events := makeTestTxV6("schedule", "sender", payload).Execute(s)
+		return rc, events
+	}
+
+	// check
+	param := ScheduleParam{
+		Recipient: sender,
+		Amount:    *new(types.Currency).Set(100),
+		Start:     110,
+	}
+	payload, _ := json.Marshal(param)
+	tx := makeTestTxV6("schedule", "sender", payload)
+	_, ok := tx.(*TxSchedule)
+	assert.True(t, ok)
+	rc, _ := tx.Check()
+	assert.Equal(t, code.TxCodeSelfTransaction, rc)
+	param.Recipient = recipient
+	param.Count = 3
+	rc, _ = schedule(param)
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	param.Interval = 10
+	param.Start = 100
+	rc, _ = schedule(param)
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	param.Start = 100 + maxScheduleDelay + 1
+	rc, _ = schedule(param)
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	param.Start = 110
+	param.UDC = 123
+	rc, _ = schedule(param)
+	assert.Equal(t, code.TxCodeUDCNotFound, rc)
+	param.UDC = 0
+	param.Count = maxScheduleCount + 1
+	param.Escrow = true
+	rc, _ = schedule(param)
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	param.Count = 3
+	param.Escrow = false
+
+	// charged at each transfer
+	rc, events := schedule(param)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, "schedule", events[0].Type)
+	assert.Equal(t, new(types.Currency).Set(1000), s.GetBalance(sender, false))
+	assert.Nil(t, s.GetSchedule(1, false).Escrow)
+
+	// funded by escrow
+	param.Escrow = true
+	param.Count = 10
+	param.Amount = *new(types.Currency).Set(101)
+	rc, _ = schedule(param)
+	assert.Equal(t, code.TxCodeNotEnoughBalance, rc)
+	param.Amount = *new(types.Currency).Set(60)
+	rc, _ = schedule(param)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, new(types.Currency).Set(400), s.GetBalance(sender, false))
+	assert.Equal(t, new(types.Currency).Set(600), s.GetSchedule(2, false).Escrow)
+
+	// cancel
+	cancel := func(sender string, id uint64) uint32 {
+		payload, _ := json.Marshal(CancelScheduleParam{ID: id})
+		rc, _, _ := makeTestTxV6("cancel_schedule", sender, payload).Execute(s)
+		return rc
+	}
+	assert.Equal(t, code.TxCodeScheduleNotFound, cancel("sender", 3))
+	assert.Equal(t, code.TxCodePermissionDenied, cancel("recipient", 2))
+	assert.Equal(t, code.TxCodeOK, cancel("sender", 2))
+	assert.Equal(t, new(types.Currency).Set(1000), s.GetBalance(sender, false))
+	assert.Equal(t, code.TxCodeOK, cancel("sender", 1))
+	assert.Nil(t, s.GetSchedule(1, false))
+	assert.Equal(t, 0, len(s.ExecuteSchedules(110, false)))
+	assert.Equal(t, uint64(0), s.GetScheduleCount(sender, false))
+
+	// limited per sender
+	param.Escrow = false
+	param.Count = 1
+	param.Amount = *new(types.Currency).Set(1)
+	for i := uint64(0); i < maxSchedulesPerSender; i++ {
+		rc, _ = schedule(param)
+		assert.Equal(t, code.TxCodeOK, rc)
+	}
+	rc, _ = schedule(param)
+	assert.Equal(t, code.TxCodeBadParam, rc)
+}
+
+func TestChannel(t *testing.T) {
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	StateBlockHeight = 100
+	defer func() { StateBlockHeight = defaultBlockHeight }()
+
+	senderKey := p256.GenPrivKeyFromSecret([]byte("sender"))
+	sender := senderKey.PubKey().Address()
+	recipient := makeAccAddr("recipient")
+	s.SetUDC(123, &types.UDC{
+		Owner: makeAccAddr("issuer"),
+		Total: *new(types.Currency).Set(1000),
+	})
+	s.SetUDCBalance(123, sender, new(types.Currency).Set(1000))
+
+	// open
+	param := ChannelOpenParam{
+		Recipient: recipient,
+		UDC:       123,
+		Amount:    *new(types.Currency).Set(500),
+	}
+	payload, _ := json.Marshal(param)
+	tx := makeTestTxV6("channel_open", "sender", payload)
+	_, ok := tx.(*TxChannelOpen)
+	assert.True(t, ok)
+	rc, _ := tx.Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	param.Period = maxChannelPeriod + 1
+	payload, _ = json.Marshal(param)
+	rc, _ = makeTestTxV6("channel_open", "sender", payload).Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	param.Period = 10
+	param.Amount = *new(types.Currency).Set(1001)
+	payload, _ = json.Marshal(param)
+	rc, _, _ = makeTestTxV6("channel_open", "sender", payload).Execute(s)
+	assert.Equal(t, code.TxCodeNotEnoughBalance, rc)
+	param.Amount = *new(types.Currency).Set(500)
+	payload, _ = json.Marshal(param)
+	rc, _, events := makeTestTxV6("channel_open", "sender", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, "channel", events[0].Type)
+	assert.Equal(t, new(types.Currency).Set(500),
+		s.GetUDCBalance(123, sender, false))
+
+	voucher := func(key p256.PrivKeyP256, amount uint64) *types.Voucher {
+		v := &types.Voucher{
+			Channel: 1,
+			Amount:  *new(types.Currency).Set(amount),
+			PubKey:  key.PubKey().(p256.PubKeyP256),
+		}
+		v.SigBytes, _ = key.Sign(v.SignBytes())
+		return v
+	}
+	closeChannel := func(from string, v *types.Voucher) uint32 {
+		payload, _ := json.Marshal(ChannelCloseParam{ID: 1, Voucher: v})
+		rc, _, _ := makeTestTxV6("channel_close", from, payload).Execute(s)
+		return rc
+	}
+
+	// close
+	assert.Equal(t, code.TxCodePermissionDenied,
+		closeChannel("other", voucher(senderKey, 100)))
+	assert.Equal(t, code.TxCodeBadSignature,
+		closeChannel("recipient",
+			voucher(p256.GenPrivKeyFromSecret([]byte("other")), 100)))
+	assert.Equal(t, code.TxCodeBadParam,
+		closeChannel("recipient", voucher(senderKey, 501)))
+	negative := voucher(senderKey, 0)
+	negative.Amount.Int.SetInt64(-100)
+	negative.SigBytes, _ = senderKey.Sign(negative.SignBytes())
+	assert.Equal(t, code.TxCodeInvalidAmount, closeChannel("sender", negative))
+	assert.False(t, s.GetChannel(1, false).Closing())
+	assert.Equal(t, code.TxCodeOK, closeChannel("sender", voucher(senderKey, 100)))
+	assert.Equal(t, int64(110), s.GetChannel(1, false).End)
+
+	// challenge
+	StateBlockHeight = 105
+	assert.Equal(t, code.TxCodeBadParam, closeChannel("recipient", nil))
+	assert.Equal(t, code.TxCodeBadParam,
+		closeChannel("recipient", voucher(senderKey, 100)))
+	assert.Equal(t, code.TxCodeOK,
+		closeChannel("recipient", voucher(senderKey, 300)))
+	assert.Equal(t, int64(110), s.GetChannel(1, false).End)
+
+	// settle
+	assert.Equal(t, 0, len(s.SettleChannels(109, false)))
+	events = s.SettleChannels(110, false)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "channel_settle", events[0].Type)
+	assert.Nil(t, s.GetChannel(1, false))
+	assert.Equal(t, new(types.Currency).Set(300),
+		s.GetUDCBalance(123, recipient, false))
+	assert.Equal(t, new(types.Currency).Set(700),
+		s.GetUDCBalance(123, sender, false))
+	assert.Equal(t, code.TxCodeChannelNotFound,
+		closeChannel("recipient", voucher(senderKey, 400)))
+}
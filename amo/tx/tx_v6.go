@@ -0,0 +1,302 @@
+package tx
+
+import (
+	"encoding/json"
+)
+
+func classifyTxV6(base TxBase) Tx {
+	var t Tx
+	// TODO: use err return from parseSomethingParam()
+	switch base.Type {
+	case "transfer":
+		param, _ := parseTransferParamV5(base.Payload)
+		t = &TxTransferV5{
+			TxBase: base,
+			Param:  param,
+		}
+	case "approve":
+		param, _ := parseApproveParam(base.Payload)
+		t = &TxApprove{
+			TxBase: base,
+			Param:  param,
+		}
+	case "stake":
+		param, _ := parseStakeParam(base.Payload)
+		t = &TxStake{
+			TxBase: base,
+			Param:  param,
+		}
+	case "withdraw":
+		param, _ := parseWithdrawParam(base.Payload)
+		t = &TxWithdraw{
+			TxBase: base,
+			Param:  param,
+		}
+	case "delegate":
+		param, _ := parseDelegateParam(base.Payload)
+		t = &TxDelegate{
+			TxBase: base,
+			Param:  param,
+		}
+	case "retract":
+		param, _ := parseRetractParam(base.Payload)
+		t = &TxRetract{
+			TxBase: base,
+			Param:  param,
+		}
+	case "setup":
+		param, _ := parseSetupParam(base.Payload)
+		t = &TxSetup{
+			TxBase: base,
+			Param:  param,
+		}
+	case "close":
+		param, _ := parseCloseParam(base.Payload)
+		t = &TxClose{
+			TxBase: base,
+			Param:  param,
+		}
+	case "register":
+		param, _ := parseRegisterParam(base.Payload)
+		t = &TxRegister{
+			TxBase: base,
+			Param:  param,
+		}
+	case "register_bulk":
+		param, _ := parseRegisterBulkParam(base.Payload)
+		t = &TxRegisterBulk{
+			TxBase: base,
+			Param:  param,
+		}
+	case "discard":
+		param, _ := parseDiscardParam(base.Payload)
+		t = &TxDiscard{
+			TxBase: base,
+			Param:  param,
+		}
+	case "operator":
+		param, _ := parseOperatorParam(base.Payload)
+		t = &TxOperator{
+			TxBase: base,
+			Param:  param,
+		}
+	case "request":
+		param, _ := parseRequestParam(base.Payload)
+		t = &TxRequest{
+			TxBase: base,
+			Param:  param,
+		}
+	case "cancel":
+		param, _ := parseCancelParam(base.Payload)
+		t = &TxCancel{
+			TxBase: base,
+			Param:  param,
+		}
+	case "cancel_all":
+		param, _ := parseCancelAllParam(base.Payload)
+		t = &TxCancelAll{
+			TxBase: base,
+			Param:  param,
+		}
+	case "grant":
+		param, _ := parseGrantParam(base.Payload)
+		t = &TxGrant{
+			TxBase: base,
+			Param:  param,
+		}
+	case "dealer":
+		param, _ := parseDealerParam(base.Payload)
+		t = &TxDealer{
+			TxBase: base,
+			Param:  param,
+		}
+	case "revoke":
+		param, _ := parseRevokeParam(base.Payload)
+		t = &TxRevoke{
+			TxBase: base,
+			Param:  param,
+		}
+	case "claim":
+		param, _ := parseClaimParam(base.Payload)
+		t = &TxClaim{
+			TxBase: base,
+			Param:  param,
+		}
+	case "dismiss":
+		param, _ := parseDismissParam(base.Payload)
+		t = &TxDismiss{
+			TxBase: base,
+			Param:  param,
+		}
+	case "transfer_did":
+		param, _ := parseTransferDIDParam(base.Payload)
+		t = &TxTransferDID{
+			TxBase: base,
+			Param:  param,
+		}
+	case "revoke_credential":
+		param, _ := parseRevokeCredentialParam(base.Payload)
+		t = &TxRevokeCredential{
+			TxBase: base,
+			Param:  param,
+		}
+	case "suspend_credential":
+		param, _ := parseSuspendCredentialParam(base.Payload)
+		t = &TxSuspendCredential{
+			TxBase: base,
+			Param:  param,
+		}
+	case "issue":
+		param, _ := parseIssueParam(base.Payload)
+		t = &TxIssue{
+			TxBase: base,
+			Param:  param,
+		}
+	case "propose":
+		param, _ := parseProposeParam(base.Payload)
+		t = &TxPropose{
+			TxBase: base,
+			Param:  param,
+		}
+	case "vote":
+		param, _ := parseVoteParam(base.Payload)
+		t = &TxVote{
+			TxBase: base,
+			Param:  param,
+		}
+	case "lock":
+		param, _ := parseLockParam(base.Payload)
+		t = &TxLock{
+			TxBase: base,
+			Param:  param,
+		}
+	case "burn":
+		param, _ := parseBurnParam(base.Payload)
+		t = &TxBurn{
+			TxBase: base,
+			Param:  param,
+		}
+	case "freeze":
+		param, _ := parseFreezeParam(base.Payload)
+		t = &TxFreeze{
+			TxBase: base,
+			Param:  param,
+		}
+	case "transfer_udc":
+		param, _ := parseTransferUDCParam(base.Payload)
+		t = &TxTransferUDC{
+			TxBase: base,
+			Param:  param,
+		}
+	case "htlc_create":
+		param, _ := parseHTLCCreateParam(base.Payload)
+		t = &TxHTLCCreate{
+			TxBase: base,
+			Param:  param,
+		}
+	case "htlc_claim":
+		param, _ := parseHTLCClaimParam(base.Payload)
+		t = &TxHTLCClaim{
+			TxBase: base,
+			Param:  param,
+		}
+	case "htlc_refund":
+		param, _ := parseHTLCRefundParam(base.Payload)
+		t = &TxHTLCRefund{
+			TxBase: base,
+			Param:  param,
+		}
+	case "place_order":
+		param, _ := parsePlaceOrderParam(base.Payload)
+		t = &TxPlaceOrder{
+			TxBase: base,
+			Param:  param,
+		}
+	case "cancel_order":
+		param, _ := parseCancelOrderParam(base.Payload)
+		t = &TxCancelOrder{
+			TxBase: base,
+			Param:  param,
+		}
+	case "schedule":
+		param, _ := parseScheduleParam(base.Payload)
+		t = &TxSchedule{
+			TxBase: base,
+			Param:  param,
+		}
+	case "cancel_schedule":
+		param, _ := parseCancelScheduleParam(base.Payload)
+		t = &TxCancelSchedule{
+			TxBase: base,
+			Param:  param,
+		}
+	case "channel_open":
+		param, _ := parseChannelOpenParam(base.Payload)
+		t = &TxChannelOpen{
+			TxBase: base,
+			Param:  param,
+		}
+	case "channel_close":
+		param, _ := parseChannelCloseParam(base.Payload)
+		t = &TxChannelClose{
+			TxBase: base,
+			Param:  param,
+		}
+	case "challenge":
+		param, _ := parseChallengeParam(base.Payload)
+		t = &TxChallenge{
+			TxBase: base,
+			Param:  param,
+		}
+	case "prove":
+		param, _ := parseProveParam(base.Payload)
+		t = &TxProve{
+			TxBase: base,
+			Param:  param,
+		}
+	case "report":
+		param, _ := parseReportParam(base.Payload)
+		t = &TxReport{
+			TxBase: base,
+			Param:  param,
+		}
+	case "confirm":
+		param, _ := parseConfirmParam(base.Payload)
+		t = &TxConfirm{
+			TxBase: base,
+			Param:  param,
+		}
+	case "dispute":
+		param, _ := parseDisputeParam(base.Payload)
+		t = &TxDispute{
+			TxBase: base,
+			Param:  param,
+		}
+	case "resolve":
+		param, _ := parseResolveParam(base.Payload)
+		t = &TxResolve{
+			TxBase: base,
+			Param:  param,
+		}
+	case "enckey":
+		param, _ := parseEncKeyParam(base.Payload)
+		t = &TxEncKey{
+			TxBase: base,
+			Param:  param,
+		}
+	default:
+		t = &base
+	}
+	return t
+}
+
+func ParseTxV6(txBytes []byte) (Tx, error) {
+	var base TxBase
+
+	err := json.Unmarshal(txBytes, &base)
+	if err != nil {
+		return nil, err
+	}
+
+	return classifyTxV6(base), nil
+}
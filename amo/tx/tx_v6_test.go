@@ -0,0 +1,192 @@
+package tx
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/crypto"
+	tmdb "github.com/tendermint/tm-db"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+	"github.com/amolabs/amoabci/crypto/p256"
+)
+
+// Txs are tested against the rules of the latest protocol, unless a test sets
+// StateProtocolVersion by itself.
+func TestMain(m *testing.M) {
+	StateProtocolVersion = 0x6
+	os.Exit(m.Run())
+}
+
+func makeTestTxV6(txType string, seed string, payload []byte) Tx {
+	privKey := p256.GenPrivKeyFromSecret([]byte(seed))
+	addr := privKey.PubKey().Address()
+	trans := TxBase{
+		Type:    txType,
+		Sender:  addr,
+		Payload: payload,
+	}
+	trans.Sign(privKey)
+	return classifyTxV6(trans)
+}
+
+func TestClassifyTxV6(t *testing.T) {
+	base := TxBase{Type: "challenge"}
+	_, ok := classifyTx(base).(*TxBase)
+	assert.True(t, ok)
+	_, ok = classifyTxV5(base).(*TxBase)
+	assert.True(t, ok)
+	_, ok = classifyTxV6(base).(*TxChallenge)
+	assert.True(t, ok)
+
+	base = TxBase{Type: "transfer"}
+	_, ok = classifyTx(base).(*TxTransfer)
+	assert.True(t, ok)
+	_, ok = classifyTxV6(base).(*TxTransferV5)
+	assert.True(t, ok)
+}
+
+func TestTxRulesBeforeV6(t *testing.T) {
+	StateProtocolVersion = 0x5
+	defer func() { StateProtocolVersion = 0x6 }()
+
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	// setup without storage bond
+	payload, _ := json.Marshal(SetupParam{
+		Storage:         1,
+		Url:             "http://need_to_check_url_format",
+		RegistrationFee: *new(types.Currency).SetAMO(1),
+		HostingFee:      *new(types.Currency).SetAMO(1),
+		Bond:            *new(types.Currency).SetAMO(10),
+	})
+	rc, _, _ := makeTestTxV5("setup", "provider", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	sto := s.GetStorage(1, false)
+	assert.NotNil(t, sto)
+	assert.Equal(t, types.Zero, &sto.Bond)
+
+	// no hold period
+	payload, _ = json.Marshal(RegisterParam{
+		Target:     []byte{0x00, 0x00, 0x00, 0x01, 0x01},
+		Custody:    []byte{0xcc},
+		HoldPeriod: 10,
+		Arbiter:    makeAccAddr("arbiter"),
+	})
+	s.SetBalance(makeAccAddr("seller"), new(types.Currency).SetAMO(1))
+	rc, _, _ = makeTestTxV5("register", "seller", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	parcel := s.GetParcel([]byte{0x00, 0x00, 0x00, 0x01, 0x01}, false)
+	assert.NotNil(t, parcel)
+	assert.Equal(t, int64(0), parcel.HoldPeriod)
+	assert.Nil(t, parcel.Arbiter)
+
+	// dealer not registered
+	payload, _ = json.Marshal(RequestParam{
+		Target:    []byte{0x00, 0x00, 0x00, 0x01, 0x01},
+		Recipient: makeAccAddr("user"),
+		Payment:   *new(types.Currency).Set(100),
+		Dealer:    makeAccAddr("dealer"),
+		DealerFee: *new(types.Currency).Set(100),
+	})
+	s.SetBalance(makeAccAddr("buyer"), new(types.Currency).Set(200))
+	rc, _, _ = makeTestTxV5("request", "buyer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+
+	// usage without agency
+	payload, _ = json.Marshal(GrantParam{
+		Target:    []byte{0x00, 0x00, 0x00, 0x01, 0x01},
+		Recipient: makeAccAddr("user"),
+		Custody:   []byte{0xcc},
+	})
+	s.SetBalance(makeAccAddr("seller"), new(types.Currency).SetAMO(1))
+	rc, _, _ = makeTestTxV5("grant", "seller", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	usage := s.GetUsage(makeAccAddr("user"),
+		[]byte{0x00, 0x00, 0x00, 0x01, 0x01}, false)
+	assert.NotNil(t, usage)
+	assert.Nil(t, usage.Agency)
+
+	// coins of the sender are transferred regardless of from
+	s.SetUDCBalance(123, makeAccAddr("owner"), new(types.Currency).Set(100))
+	s.SetUDCAllowance(123, makeAccAddr("owner"), makeAccAddr("spender"),
+		new(types.Currency).Set(100))
+	payload, _ = json.Marshal(TransferParamV5{
+		UDC:    123,
+		From:   makeAccAddr("owner"),
+		To:     makeAccAddr("shop"),
+		Amount: *new(types.Currency).Set(100),
+	})
+	rc, _, _ = makeTestTxV5("transfer", "spender", payload).Execute(s)
+	assert.Equal(t, code.TxCodeNotEnoughBalance, rc)
+	assert.Equal(t, new(types.Currency).Set(100),
+		s.GetUDCBalance(123, makeAccAddr("owner"), false))
+
+	// operators can mint and lock, and every field gets replaced
+	s.SetUDC(123, &types.UDC{
+		Owner:     makeAccAddr("owner"),
+		Desc:      "mycoin",
+		Operators: []crypto.Address{makeAccAddr("operator")},
+		Total:     *new(types.Currency).Set(100),
+	})
+	payload, _ = json.Marshal(IssueParam{
+		UDC:       123,
+		Operators: []crypto.Address{makeAccAddr("operator")},
+		Amount:    *new(types.Currency).Set(100),
+	})
+	rc, _, _ = makeTestTxV5("issue", "operator", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	udc := s.GetUDC(123, false)
+	assert.Equal(t, "", udc.Desc)
+	assert.Equal(t, new(types.Currency).Set(200), &udc.Total)
+	payload, _ = json.Marshal(LockParam{
+		UDC:    123,
+		Holder: makeAccAddr("owner"),
+		Amount: *new(types.Currency).Set(50),
+		Cliff:  100,
+	})
+	rc, _, _ = makeTestTxV5("lock", "operator", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, &types.UDCLock{Amount: *new(types.Currency).Set(50)},
+		s.GetUDCLockSchedule(123, makeAccAddr("owner"), false))
+
+	// anyone can claim any document, and dismiss removes the entry
+	payload, _ = json.Marshal(ClaimParam{
+		Target:   "did:amo:myid",
+		Document: []byte(`{"id":"other"}`),
+	})
+	tx := makeTestTxV5("claim", "sender", payload)
+	rc, _ = tx.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = makeTestTxV5("claim", "other", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	entry := s.GetDIDEntry("did:amo:myid", false)
+	assert.Equal(t, makeAccAddr("other"), entry.Owner)
+	assert.Equal(t, uint64(0), entry.Version)
+	assert.Empty(t, s.GetDIDVersions("did:amo:myid", false))
+	payload, _ = json.Marshal(DismissParam{Target: "did:amo:myid"})
+	rc, _, _ = makeTestTxV5("dismiss", "sender", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Nil(t, s.GetDIDEntry("did:amo:myid", false))
+
+	// re-register by proxy takes over the parcel
+	payload, _ = json.Marshal(RegisterParam{
+		Target:       []byte{0x00, 0x00, 0x00, 0x01, 0x02},
+		Custody:      []byte{0xcc},
+		ProxyAccount: makeAccAddr("proxy"),
+	})
+	s.SetBalance(makeAccAddr("seller"), new(types.Currency).SetAMO(1))
+	rc, _, _ = makeTestTxV5("register", "seller", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = makeTestTxV5("register", "proxy", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	parcel = s.GetParcel([]byte{0x00, 0x00, 0x00, 0x01, 0x02}, false)
+	assert.Equal(t, makeAccAddr("proxy"), parcel.Owner)
+}
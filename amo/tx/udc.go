@@ -0,0 +1,195 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+// checkUDCFrozen checks if the UDC is frozen as a whole or for any of the
+// holders given.
+func checkUDCFrozen(s *store.Store, udc uint32, holders ...crypto.Address) (uint32, string) {
+	if udc == 0 {
+		return code.TxCodeOK, "ok"
+	}
+	u := s.GetUDC(udc, false)
+	if u != nil && u.Frozen {
+		return code.TxCodeFrozen, "UDC frozen"
+	}
+	for _, holder := range holders {
+		if s.IsUDCFrozen(udc, holder, false) {
+			return code.TxCodeFrozen, "holder frozen"
+		}
+	}
+	return code.TxCodeOK, "ok"
+}
+
+// escrowCoin takes the amount of coins out of the balance of the holder to
+// hold them in escrow. Locked coins cannot be taken.
+func escrowCoin(s *store.Store, udc uint32, holder crypto.Address,
+	amount *types.Currency) (uint32, string) {
+	if udc != 0 && s.GetUDC(udc, false) == nil {
+		return code.TxCodeUDCNotFound, "UDC not found"
+	}
+	if rc, info := checkUDCFrozen(s, udc, holder); rc != code.TxCodeOK {
+		return rc, info
+	}
+	balance := s.GetUDCBalance(udc, holder, false)
+	required := getLockedUDC(s, udc, holder)
+	required.Add(amount)
+	if balance.LessThan(required) {
+		return code.TxCodeNotEnoughBalance, "not enough balance"
+	}
+	balance.Sub(amount)
+	s.SetUDCBalance(udc, holder, balance)
+	return code.TxCodeOK, "ok"
+}
+
+// releaseCoin pays the amount of coins held in escrow to the holder other than
+// the one who put them in. The payment is not made while the holder is frozen.
+func releaseCoin(s *store.Store, udc uint32, holder crypto.Address,
+	amount *types.Currency) (uint32, string) {
+	if rc, info := checkUDCFrozen(s, udc, holder); rc != code.TxCodeOK {
+		return rc, info
+	}
+	refundCoin(s, udc, holder, amount)
+	return code.TxCodeOK, "ok"
+}
+
+// refundCoin returns the amount of coins held in escrow to the holder who put
+// them in. Unlike releaseCoin, it is made regardless of freezing, as the coins
+// go back where they were.
+func refundCoin(s *store.Store, udc uint32, holder crypto.Address,
+	amount *types.Currency) {
+	balance := s.GetUDCBalance(udc, holder, false)
+	balance.Add(amount)
+	s.SetUDCBalance(udc, holder, balance)
+}
+
+//// freeze
+
+type FreezeParam struct {
+	UDC    uint32         `json:"udc"`
+	Holder crypto.Address `json:"holder,omitempty"` // empty for the whole UDC
+	Frozen bool           `json:"frozen"`           // false to unfreeze
+}
+
+func parseFreezeParam(raw []byte) (FreezeParam, error) {
+	var param FreezeParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxFreeze struct {
+	TxBase
+	Param FreezeParam `json:"-"`
+}
+
+var _ Tx = &TxFreeze{}
+
+func (t *TxFreeze) Check() (uint32, string) {
+	param, err := parseFreezeParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(param.Holder) > 0 && len(param.Holder) != crypto.AddressSize {
+		return code.TxCodeBadParam, "wrong size of holder address"
+	}
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxFreeze) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	param := t.Param
+
+	udc := s.GetUDC(param.UDC, false)
+	if udc == nil {
+		return code.TxCodeUDCNotFound, "UDC not found", nil
+	}
+	if !udc.IsAdmin(t.GetSender()) {
+		return code.TxCodePermissionDenied, "permission denied", nil
+	}
+
+	if len(param.Holder) == 0 {
+		udc.Frozen = param.Frozen
+		err := s.SetUDC(param.UDC, udc)
+		if err != nil {
+			return code.TxCodeUnknown, err.Error(), nil
+		}
+	} else {
+		s.SetUDCFrozen(param.UDC, param.Holder, param.Frozen)
+	}
+
+	return code.TxCodeOK, "ok", nil
+}
+
+//// transfer_udc
+
+type TransferUDCParam struct {
+	UDC   uint32         `json:"udc"`
+	Owner crypto.Address `json:"owner"`
+}
+
+func parseTransferUDCParam(raw []byte) (TransferUDCParam, error) {
+	var param TransferUDCParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxTransferUDC struct {
+	TxBase
+	Param TransferUDCParam `json:"-"`
+}
+
+var _ Tx = &TxTransferUDC{}
+
+func (t *TxTransferUDC) Check() (uint32, string) {
+	param, err := parseTransferUDCParam(t.getPayload())
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(param.Owner) != crypto.AddressSize {
+		return code.TxCodeBadParam, "wrong size of owner address"
+	}
+	if bytes.Equal(t.GetSender(), param.Owner) {
+		return code.TxCodeSelfTransaction, "tried to transfer to self"
+	}
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxTransferUDC) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	if rc, info := t.Check(); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+	param := t.Param
+
+	udc := s.GetUDC(param.UDC, false)
+	if udc == nil {
+		return code.TxCodeUDCNotFound, "UDC not found", nil
+	}
+	if !bytes.Equal(t.GetSender(), udc.Owner) {
+		return code.TxCodePermissionDenied, "permission denied", nil
+	}
+
+	udc.Owner = param.Owner
+	err := s.SetUDC(param.UDC, udc)
+	if err != nil {
+		return code.TxCodeUnknown, err.Error(), nil
+	}
+
+	return code.TxCodeOK, "ok", nil
+}
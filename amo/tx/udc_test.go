@@ -89,16 +89,35 @@ func TestTxIssue(t *testing.T) {
 	assert.NotNil(t, udc)
 	assert.Equal(t, *new(types.Currency).Set(2000000), udc.Total)
 
-	// change fields other than total
+	// change operators (fail: owner only)
 	param = IssueParam{
 		UDC:       123,
 		Operators: []crypto.Address{makeAccAddr("oper2")},
-		Desc:      "my own coin",
 		Amount:    *new(types.Currency).Set(0),
 	}
 	payload, _ = json.Marshal(param)
 	tx = makeTestTx("issue", "oper1", payload)
 	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+
+	// change fields other than total
+	param = IssueParam{
+		UDC:    123,
+		Desc:   "my own coin",
+		Amount: *new(types.Currency).Set(0),
+	}
+	payload, _ = json.Marshal(param)
+	tx = makeTestTx("issue", "oper1", payload)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	param = IssueParam{
+		UDC:       123,
+		Operators: []crypto.Address{makeAccAddr("oper2")},
+		Amount:    *new(types.Currency).Set(0),
+	}
+	payload, _ = json.Marshal(param)
+	tx = makeTestTx("issue", "issuer", payload)
+	rc, _, _ = tx.Execute(s)
 	assert.Equal(t, code.TxCodeOK, rc)
 	// check
 	udc = s.GetUDC(123, false)
@@ -259,12 +278,12 @@ func TestUDCLock(t *testing.T) {
 	assert.Equal(t, code.TxCodeUDCNotFound, rc)
 
 	mycoin := &types.UDC{
-		makeAccAddr("issuer"),
-		"mycoin for test",
-		[]crypto.Address{
+		Owner: makeAccAddr("issuer"),
+		Desc:  "mycoin for test",
+		Lockers: []crypto.Address{
 			makeAccAddr("op1"),
 		},
-		*new(types.Currency).SetAMO(100),
+		Total: *new(types.Currency).SetAMO(100),
 	}
 	assert.NotNil(t, mycoin)
 	assert.NoError(t, s.SetUDC(uint32(123), mycoin))
@@ -379,12 +398,12 @@ func TestUDCBurn(t *testing.T) {
 	assert.Equal(t, code.TxCodeUDCNotFound, rc)
 
 	mycoin := &types.UDC{
-		makeAccAddr("issuer"),
-		"mycoin for test",
-		[]crypto.Address{
+		Owner: makeAccAddr("issuer"),
+		Desc:  "mycoin for test",
+		Operators: []crypto.Address{
 			makeAccAddr("op1"),
 		},
-		*new(types.Currency).SetAMO(100),
+		Total: *new(types.Currency).SetAMO(100),
 	}
 	assert.NotNil(t, mycoin)
 	assert.NoError(t, s.SetUDC(uint32(123), mycoin))
@@ -402,3 +421,385 @@ func TestUDCBurn(t *testing.T) {
 	mycoinAfter := s.GetUDC(uint32(123), false)
 	assert.Equal(t, *mycoin.Total.Sub(new(types.Currency).SetAMO(10)), mycoinAfter.Total)
 }
+
+func TestUDCAllowance(t *testing.T) {
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	owner := makeAccAddr("owner")
+	spender := makeAccAddr("spender")
+	shop := makeAccAddr("shop")
+	s.SetUDC(123, &types.UDC{Total: *new(types.Currency).Set(1000)})
+	s.SetUDCBalance(123, owner, new(types.Currency).Set(1000))
+	s.SetUDCLock(123, owner, new(types.Currency).Set(100))
+
+	// approve
+	payload, _ := json.Marshal(ApproveParam{
+		UDC:     124,
+		Spender: spender,
+		Amount:  *new(types.Currency).Set(500),
+	})
+	rc, _, _ := makeTestTxV6("approve", "owner", payload).Execute(s)
+	assert.Equal(t, code.TxCodeUDCNotFound, rc)
+	payload, _ = json.Marshal(ApproveParam{
+		UDC:     123,
+		Spender: owner,
+		Amount:  *new(types.Currency).Set(500),
+	})
+	rc, _ = makeTestTxV6("approve", "owner", payload).Check()
+	assert.Equal(t, code.TxCodeSelfTransaction, rc)
+	payload, _ = json.Marshal(ApproveParam{
+		UDC:     123,
+		Spender: spender,
+		Amount:  *new(types.Currency).Set(500),
+	})
+	tx := makeTestTxV6("approve", "owner", payload)
+	rc, _ = tx.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, new(types.Currency).Set(500),
+		s.GetUDCAllowance(123, owner, spender, false))
+
+	// transfer from
+	payload, _ = json.Marshal(TransferParamV5{
+		UDC:    123,
+		From:   owner,
+		To:     owner,
+		Amount: *new(types.Currency).Set(100),
+	})
+	rc, _ = makeTestTxV6("transfer", "spender", payload).Check()
+	assert.Equal(t, code.TxCodeSelfTransaction, rc)
+	payload, _ = json.Marshal(TransferParamV5{
+		UDC:    123,
+		From:   owner,
+		To:     shop,
+		Amount: *new(types.Currency).Set(300),
+	})
+	rc, _, _ = makeTestTxV6("transfer", "shop", payload).Execute(s)
+	assert.Equal(t, code.TxCodeNotEnoughAllowance, rc)
+	tx = makeTestTxV6("transfer", "spender", payload)
+	rc, _ = tx.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, new(types.Currency).Set(700),
+		s.GetUDCBalance(123, owner, false))
+	assert.Equal(t, new(types.Currency).Set(300),
+		s.GetUDCBalance(123, shop, false))
+	assert.Equal(t, new(types.Currency).Set(200),
+		s.GetUDCAllowance(123, owner, spender, false))
+
+	// not enough allowance
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeNotEnoughAllowance, rc)
+	assert.Equal(t, new(types.Currency).Set(700),
+		s.GetUDCBalance(123, owner, false))
+
+	// locked coins cannot be spent
+	payload, _ = json.Marshal(ApproveParam{
+		UDC:     123,
+		Spender: spender,
+		Amount:  *new(types.Currency).Set(1000),
+	})
+	rc, _, _ = makeTestTxV6("approve", "owner", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	payload, _ = json.Marshal(TransferParamV5{
+		UDC:    123,
+		From:   owner,
+		To:     shop,
+		Amount: *new(types.Currency).Set(700),
+	})
+	rc, _, _ = makeTestTxV6("transfer", "spender", payload).Execute(s)
+	assert.Equal(t, code.TxCodeNotEnoughBalance, rc)
+
+	// revoke the allowance
+	payload, _ = json.Marshal(ApproveParam{
+		UDC:     123,
+		Spender: spender,
+		Amount:  *new(types.Currency).Set(0),
+	})
+	rc, _, _ = makeTestTxV6("approve", "owner", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, new(types.Currency).Set(0),
+		s.GetUDCAllowance(123, owner, spender, false))
+}
+
+func TestUDCLockSchedule(t *testing.T) {
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	holder := makeAccAddr("holder")
+	s.SetUDC(123, &types.UDC{
+		Owner: makeAccAddr("issuer"),
+		Total: *new(types.Currency).Set(1000),
+	})
+	s.SetUDCBalance(123, holder, new(types.Currency).Set(1000))
+
+	StateBlockHeight = 100
+	defer func() { StateBlockHeight = defaultBlockHeight }()
+
+	payload, _ := json.Marshal(LockParam{
+		UDC:    123,
+		Holder: holder,
+		Amount: *new(types.Currency).Set(1000),
+		Cliff:  300,
+		End:    200,
+	})
+	rc, _ := makeTestTx("lock", "issuer", payload).Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	payload, _ = json.Marshal(LockParam{
+		UDC:    123,
+		Holder: holder,
+		Amount: *new(types.Currency).Set(1000),
+		End:    50,
+	})
+	rc, _, _ = makeTestTx("lock", "issuer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeBadParam, rc)
+
+	// cliff at 150, fully released at 200
+	payload, _ = json.Marshal(LockParam{
+		UDC:    123,
+		Holder: holder,
+		Amount: *new(types.Currency).Set(1000),
+		Cliff:  150,
+		End:    200,
+	})
+	rc, _, _ = makeTestTx("lock", "issuer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, &types.UDCLock{
+		Amount: *new(types.Currency).Set(1000),
+		Start:  100,
+		Cliff:  150,
+		End:    200,
+	}, s.GetUDCLockSchedule(123, holder, false))
+
+	transfer := func(amount uint64) uint32 {
+		payload, _ := json.Marshal(TransferParamV5{
+			UDC:    123,
+			To:     makeAccAddr("recp"),
+			Amount: *new(types.Currency).Set(amount),
+		})
+		rc, _, _ := makeTestTxV6("transfer", "holder", payload).Execute(s)
+		return rc
+	}
+	burn := func(amount uint64) uint32 {
+		payload, _ := json.Marshal(BurnParam{
+			UDC:    123,
+			Amount: *new(types.Currency).Set(amount),
+		})
+		rc, _, _ := makeTestTx("burn", "holder", payload).Execute(s)
+		return rc
+	}
+
+	// before cliff
+	StateBlockHeight = 149
+	assert.Equal(t, code.TxCodeNotEnoughBalance, transfer(1))
+	assert.Equal(t, code.TxCodeNotEnoughBalance, burn(1))
+
+	// at cliff, half of the amount is vested
+	StateBlockHeight = 150
+	assert.Equal(t, code.TxCodeNotEnoughBalance, transfer(501))
+	assert.Equal(t, code.TxCodeOK, transfer(400))
+	assert.Equal(t, code.TxCodeOK, burn(100))
+	assert.Equal(t, code.TxCodeNotEnoughBalance, burn(1))
+
+	// linear vesting
+	StateBlockHeight = 180
+	assert.Equal(t, new(types.Currency).Set(200),
+		getLockedUDC(s, 123, holder))
+	assert.Equal(t, code.TxCodeOK, transfer(300))
+
+	// fully released
+	StateBlockHeight = 200
+	assert.Equal(t, new(types.Currency).Set(0), getLockedUDC(s, 123, holder))
+	assert.Equal(t, code.TxCodeOK, burn(200))
+
+	// expiry without vesting
+	s.SetUDCBalance(123, holder, new(types.Currency).Set(1000))
+	payload, _ = json.Marshal(LockParam{
+		UDC:    123,
+		Holder: holder,
+		Amount: *new(types.Currency).Set(1000),
+		Cliff:  300,
+	})
+	rc, _, _ = makeTestTx("lock", "issuer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	StateBlockHeight = 299
+	assert.Equal(t, code.TxCodeNotEnoughBalance, transfer(1))
+	StateBlockHeight = 300
+	assert.Equal(t, code.TxCodeOK, transfer(1000))
+}
+
+func TestUDCRoles(t *testing.T) {
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	s.SetUDC(123, &types.UDC{
+		Owner:     makeAccAddr("issuer"),
+		Operators: []crypto.Address{makeAccAddr("admin")},
+		Minters:   []crypto.Address{makeAccAddr("minter")},
+		MaxSupply: *new(types.Currency).Set(1500),
+		Total:     *new(types.Currency).Set(1000),
+	})
+	s.SetUDCBalance(123, makeAccAddr("issuer"), new(types.Currency).Set(1000))
+
+	issue := func(sender string, amount uint64) uint32 {
+		payload, _ := json.Marshal(IssueParam{
+			UDC:    123,
+			Amount: *new(types.Currency).Set(amount),
+		})
+		rc, _, _ := makeTestTx("issue", sender, payload).Execute(s)
+		return rc
+	}
+
+	// only minters can issue
+	assert.Equal(t, code.TxCodePermissionDenied, issue("admin", 100))
+	assert.Equal(t, code.TxCodeOK, issue("minter", 400))
+	assert.Equal(t, new(types.Currency).Set(400),
+		s.GetUDCBalance(123, makeAccAddr("minter"), false))
+	// cap
+	assert.Equal(t, code.TxCodeMaxSupplyExceeded, issue("minter", 101))
+	assert.Equal(t, code.TxCodeOK, issue("issuer", 100))
+	assert.Equal(t, *new(types.Currency).Set(1500), s.GetUDC(123, false).Total)
+
+	// only the owner can raise the cap
+	maxSupply := new(types.Currency).Set(2000)
+	payload, _ := json.Marshal(IssueParam{UDC: 123, MaxSupply: maxSupply})
+	rc, _, _ := makeTestTx("issue", "admin", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+	rc, _, _ = makeTestTx("issue", "issuer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, code.TxCodeOK, issue("minter", 500))
+	// cap below the total
+	payload, _ = json.Marshal(IssueParam{
+		UDC:       123,
+		MaxSupply: new(types.Currency).Set(1000),
+	})
+	rc, _, _ = makeTestTx("issue", "issuer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeMaxSupplyExceeded, rc)
+
+	// admins manage minters
+	payload, _ = json.Marshal(IssueParam{
+		UDC:     123,
+		Minters: []crypto.Address{makeAccAddr("admin")},
+	})
+	rc, _, _ = makeTestTx("issue", "minter", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+	rc, _, _ = makeTestTx("issue", "admin", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, code.TxCodePermissionDenied, issue("minter", 1))
+}
+
+func TestUDCFreeze(t *testing.T) {
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	holder := makeAccAddr("holder")
+	s.SetUDC(123, &types.UDC{
+		Owner:     makeAccAddr("issuer"),
+		Operators: []crypto.Address{makeAccAddr("admin")},
+		Total:     *new(types.Currency).Set(1000),
+	})
+	s.SetUDCBalance(123, holder, new(types.Currency).Set(1000))
+
+	freeze := func(sender string, holder crypto.Address, frozen bool) uint32 {
+		payload, _ := json.Marshal(FreezeParam{
+			UDC:    123,
+			Holder: holder,
+			Frozen: frozen,
+		})
+		rc, _, _ := makeTestTxV6("freeze", sender, payload).Execute(s)
+		return rc
+	}
+	transfer := func(sender string, to crypto.Address) uint32 {
+		payload, _ := json.Marshal(TransferParamV5{
+			UDC:    123,
+			To:     to,
+			Amount: *new(types.Currency).Set(10),
+		})
+		rc, _, _ := makeTestTxV6("transfer", sender, payload).Execute(s)
+		return rc
+	}
+
+	payload, _ := json.Marshal(FreezeParam{UDC: 124, Frozen: true})
+	tx := makeTestTxV6("freeze", "issuer", payload)
+	_, ok := tx.(*TxFreeze)
+	assert.True(t, ok)
+	rc, _, _ := tx.Execute(s)
+	assert.Equal(t, code.TxCodeUDCNotFound, rc)
+	assert.Equal(t, code.TxCodePermissionDenied,
+		freeze("holder", holder, true))
+
+	// freeze a holder
+	assert.Equal(t, code.TxCodeOK, freeze("admin", holder, true))
+	assert.True(t, s.IsUDCFrozen(123, holder, false))
+	assert.Equal(t, code.TxCodeFrozen, transfer("holder", makeAccAddr("recp")))
+	payload, _ = json.Marshal(BurnParam{
+		UDC:    123,
+		Amount: *new(types.Currency).Set(10),
+	})
+	rc, _, _ = makeTestTx("burn", "holder", payload).Execute(s)
+	assert.Equal(t, code.TxCodeFrozen, rc)
+	assert.Equal(t, code.TxCodeOK, freeze("admin", holder, false))
+	assert.Equal(t, code.TxCodeOK, transfer("holder", makeAccAddr("recp")))
+
+	// frozen recipient
+	assert.Equal(t, code.TxCodeOK, freeze("issuer", makeAccAddr("recp"), true))
+	assert.Equal(t, code.TxCodeFrozen, transfer("holder", makeAccAddr("recp")))
+	assert.Equal(t, code.TxCodeOK, transfer("holder", makeAccAddr("other")))
+
+	// freeze the whole UDC
+	assert.Equal(t, code.TxCodeOK, freeze("issuer", nil, true))
+	assert.True(t, s.GetUDC(123, false).Frozen)
+	assert.Equal(t, code.TxCodeFrozen, transfer("holder", makeAccAddr("other")))
+	assert.Equal(t, code.TxCodeOK, freeze("issuer", nil, false))
+	assert.Equal(t, code.TxCodeOK, transfer("holder", makeAccAddr("other")))
+	assert.Equal(t, new(types.Currency).Set(970),
+		s.GetUDCBalance(123, holder, false))
+}
+
+func TestTransferUDC(t *testing.T) {
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	s.SetUDC(123, &types.UDC{
+		Owner:     makeAccAddr("issuer"),
+		Operators: []crypto.Address{makeAccAddr("admin")},
+		Total:     *new(types.Currency).Set(1000),
+	})
+
+	payload, _ := json.Marshal(TransferUDCParam{
+		UDC:   123,
+		Owner: makeAccAddr("issuer"),
+	})
+	tx := makeTestTxV6("transfer_udc", "issuer", payload)
+	_, ok := tx.(*TxTransferUDC)
+	assert.True(t, ok)
+	rc, _ := tx.Check()
+	assert.Equal(t, code.TxCodeSelfTransaction, rc)
+
+	payload, _ = json.Marshal(TransferUDCParam{
+		UDC:   124,
+		Owner: makeAccAddr("newowner"),
+	})
+	rc, _, _ = makeTestTxV6("transfer_udc", "issuer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeUDCNotFound, rc)
+
+	payload, _ = json.Marshal(TransferUDCParam{
+		UDC:   123,
+		Owner: makeAccAddr("newowner"),
+	})
+	rc, _, _ = makeTestTxV6("transfer_udc", "admin", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+	rc, _, _ = makeTestTxV6("transfer_udc", "issuer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, makeAccAddr("newowner"), s.GetUDC(123, false).Owner)
+	rc, _, _ = makeTestTxV6("transfer_udc", "issuer", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+}
@@ -0,0 +1,8 @@
+package types
+
+// AgencyEx is a list of requests and usages made by an agency on behalf of
+// recipients.
+type AgencyEx struct {
+	Requests []*RequestEx `json:"requests"`
+	Usages   []*UsageEx   `json:"usages"`
+}
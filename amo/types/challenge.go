@@ -0,0 +1,27 @@
+package types
+
+import (
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/libs/bytes"
+)
+
+// Challenge asks a storage owner to prove that it still holds a chunk of a
+// parcel's content. The owner must answer with a merkle proof of the chunk at
+// *Index* against *ContentRoot*, the content root of the parcel at the time
+// of the challenge, until *Deadline*. The challenger puts up *Deposit*, which
+// goes to the storage owner when proven, and returns to the challenger
+// otherwise.
+type Challenge struct {
+	Challenger  crypto.Address `json:"challenger"`
+	ContentRoot bytes.HexBytes `json:"content_root"`
+	ChunkCount  uint64         `json:"chunk_count"`
+	Index       uint64         `json:"index"`
+	Start       int64          `json:"start"`
+	Deadline    int64          `json:"deadline"`
+	Deposit     Currency       `json:"deposit"`
+}
+
+type ChallengeEx struct {
+	*Challenge
+	Parcel bytes.HexBytes `json:"parcel"`
+}
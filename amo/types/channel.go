@@ -0,0 +1,59 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/tendermint/tendermint/crypto"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+
+	"github.com/amolabs/amoabci/crypto/p256"
+)
+
+// Channel is a unidirectional payment channel, through which the sender pays
+// the recipient off-chain by signing vouchers of cumulative amounts. Once
+// either party closes it, the recipient gets the amount of the highest
+// voucher submitted until the end of the challenge period, and the sender
+// gets the rest of the deposit.
+type Channel struct {
+	Sender    crypto.Address `json:"sender"`
+	Recipient crypto.Address `json:"recipient"`
+	UDC       uint32         `json:"udc,omitempty"`
+	Deposit   Currency       `json:"deposit"`
+	Period    int64          `json:"period"`        // challenge period in blocks
+	Paid      Currency       `json:"paid"`          // amount of the highest voucher
+	End       int64          `json:"end,omitempty"` // 0 while open
+}
+
+type ChannelEx struct {
+	ID uint64 `json:"id"`
+	*Channel
+}
+
+// Voucher is signed by the sender of a channel to pay the amount in total.
+type Voucher struct {
+	Channel  uint64           `json:"channel"`
+	Amount   Currency         `json:"amount"`
+	PubKey   p256.PubKeyP256  `json:"pubkey"`
+	SigBytes tmbytes.HexBytes `json:"sig_bytes"`
+}
+
+func (c *Channel) Closing() bool {
+	return c.End > 0
+}
+
+func (v *Voucher) SignBytes() []byte {
+	b, _ := json.Marshal(struct {
+		Channel uint64   `json:"channel"`
+		Amount  Currency `json:"amount"`
+	}{v.Channel, v.Amount})
+	return b
+}
+
+// Verify checks if the voucher is signed by the sender of the channel.
+func (v *Voucher) Verify(sender crypto.Address) bool {
+	if !bytes.Equal(v.PubKey.Address(), sender) {
+		return false
+	}
+	return v.PubKey.VerifyBytes(v.SignBytes(), v.SigBytes)
+}
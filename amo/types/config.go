@@ -35,6 +35,11 @@ const (
 	DefaultDraftPassRate   = float64(0.51)
 	DefaultDraftRefundRate = float64(0.2)
 
+	DefaultStorageBond         = "100000000000000000000000"
+	DefaultChallengePeriod     = int64(100)
+	DefaultChallengeSlashRatio = float64(0.01)
+	DefaultChallengeDeposit    = "1000000000000000000"
+
 	DefaultUpgradeProtocolHeight  = int64(1)
 	DefaultUpgradeProtocolVersion = uint64(0)
 )
@@ -61,6 +66,10 @@ type AMOAppConfig struct {
 	DraftQuorumRate        float64  `json:"draft_quorum_rate"`
 	DraftPassRate          float64  `json:"draft_pass_rate"`
 	DraftRefundRate        float64  `json:"draft_refund_rate"`
+	StorageBond            Currency `json:"storage_bond"`
+	ChallengePeriod        int64    `json:"challenge_period"`
+	ChallengeSlashRatio    float64  `json:"challenge_slash_ratio"`
+	ChallengeDeposit       Currency `json:"challenge_deposit"`
 	UpgradeProtocolHeight  int64    `json:"upgrade_protocol_height"`
 	UpgradeProtocolVersion uint64   `json:"upgrade_protocol_version"`
 }
@@ -84,6 +93,8 @@ func NewDefaultAMOAppConfig() (AMOAppConfig, error) {
 		DraftQuorumRate:        DefaultDraftQuorumRate,
 		DraftPassRate:          DefaultDraftPassRate,
 		DraftRefundRate:        DefaultDraftRefundRate,
+		ChallengePeriod:        DefaultChallengePeriod,
+		ChallengeSlashRatio:    DefaultChallengeSlashRatio,
 		UpgradeProtocolHeight:  DefaultUpgradeProtocolHeight,
 		UpgradeProtocolVersion: DefaultUpgradeProtocolVersion,
 	}
@@ -112,6 +123,18 @@ func NewDefaultAMOAppConfig() (AMOAppConfig, error) {
 	}
 	cfg.DraftDeposit = *tmp
 
+	tmp, err = new(Currency).SetString(DefaultStorageBond, 10)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.StorageBond = *tmp
+
+	tmp, err = new(Currency).SetString(DefaultChallengeDeposit, 10)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.ChallengeDeposit = *tmp
+
 	return cfg, nil
 }
 
@@ -253,7 +276,12 @@ func (cfg *AMOAppConfig) Check(
 		cmp(tmpCfg.DraftDeposit, ">=", *Zero) &&
 		cmp(tmpCfg.DraftQuorumRate, ">", float64(0)) &&
 		cmp(tmpCfg.DraftPassRate, ">", float64(0)) &&
-		cmp(tmpCfg.DraftRefundRate, ">", float64(0)) {
+		cmp(tmpCfg.DraftRefundRate, ">", float64(0)) &&
+		cmp(tmpCfg.StorageBond, ">=", *Zero) &&
+		cmp(tmpCfg.ChallengePeriod, ">", int64(0)) &&
+		cmp(tmpCfg.ChallengeSlashRatio, ">=", float64(0)) &&
+		cmp(tmpCfg.ChallengeSlashRatio, "<=", float64(1)) &&
+		cmp(tmpCfg.ChallengeDeposit, ">=", *Zero) {
 		return tmpCfg, nil
 	}
 
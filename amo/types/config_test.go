@@ -29,6 +29,7 @@ func TestConfigCheckValue(t *testing.T) {
 		DraftQuorumRate:    float64(0.1),
 		DraftPassRate:      float64(0.7),
 		DraftRefundRate:    float64(0.2),
+		ChallengePeriod:    int64(100),
 	}
 
 	height := int64(1)
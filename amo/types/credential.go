@@ -0,0 +1,21 @@
+package types
+
+const (
+	CredentialActive    = "active"
+	CredentialRevoked   = "revoked"
+	CredentialSuspended = "suspended"
+)
+
+// CredentialStatus is an entry of the revocation list published by the issuer
+// of a verifiable credential.
+type CredentialStatus struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	Height int64  `json:"height,omitempty"` // height at which the status is set
+}
+
+type CredentialStatusEx struct {
+	*CredentialStatus
+	Issuer     string `json:"issuer"`
+	Credential string `json:"credential"`
+}
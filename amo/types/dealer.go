@@ -0,0 +1,14 @@
+package types
+
+import (
+	"encoding/json"
+)
+
+// Dealer is an intermediary registered on chain. A dealer fee in a request
+// may not exceed *MaxFeeRate* of the payment.
+type Dealer struct {
+	MaxFeeRate float64         `json:"max_fee_rate"`
+	Metadata   json.RawMessage `json:"metadata,omitempty"`
+	Volume     Currency        `json:"volume"` // cumulative payments of grants
+	Fees       Currency        `json:"fees"`   // cumulative dealer fees
+}
@@ -1,12 +1,354 @@
 package types
 
 import (
+	"crypto/elliptic"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+)
+
+const (
+	DIDMethod = "amo"
+
+	DIDResolutionContext = "https://w3id.org/did-resolution/v1"
+	DIDContentTypeLD     = "application/did+ld+json"
+	DIDContentTypeJSON   = "application/did+json"
+)
+
+var (
+	// did = "did:" method-name ":" method-specific-id
+	didRegexp = regexp.MustCompile(
+		`^did:[a-z0-9]+:(([A-Za-z0-9._-]|%[0-9A-Fa-f]{2})*:)*` +
+			`([A-Za-z0-9._-]|%[0-9A-Fa-f]{2})+$`)
 )
 
 type DIDEntry struct {
-	Owner    crypto.Address  `json:"owner"`
-	Document json.RawMessage `json:"document"`
+	Owner       crypto.Address  `json:"owner"`
+	Document    json.RawMessage `json:"document"`
+	Created     int64           `json:"created,omitempty"`
+	Updated     int64           `json:"updated,omitempty"`
+	Deactivated bool            `json:"deactivated,omitempty"`
+	Version     uint64          `json:"version,omitempty"`
+}
+
+// DIDDocument holds the properties of a DID document defined in the DID Core
+// data model, which are subject to validation.
+type DIDDocument struct {
+	Context              interface{}          `json:"@context,omitempty"`
+	ID                   string               `json:"id"`
+	Controller           interface{}          `json:"controller,omitempty"`
+	VerificationMethod   []VerificationMethod `json:"verificationMethod,omitempty"`
+	Authentication       []json.RawMessage    `json:"authentication,omitempty"`
+	AssertionMethod      []json.RawMessage    `json:"assertionMethod,omitempty"`
+	KeyAgreement         []json.RawMessage    `json:"keyAgreement,omitempty"`
+	CapabilityInvocation []json.RawMessage    `json:"capabilityInvocation,omitempty"`
+	CapabilityDelegation []json.RawMessage    `json:"capabilityDelegation,omitempty"`
+	Service              []DIDService         `json:"service,omitempty"`
+}
+
+type VerificationMethod struct {
+	ID                 string `json:"id"`
+	Type               string `json:"type"`
+	Controller         string `json:"controller"`
+	PublicKeyJwk       *JWK   `json:"publicKeyJwk,omitempty"`
+	PublicKeyMultibase string `json:"publicKeyMultibase,omitempty"`
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	D   string `json:"d,omitempty"`
+}
+
+type DIDService struct {
+	ID              string          `json:"id"`
+	Type            interface{}     `json:"type"`
+	ServiceEndpoint json.RawMessage `json:"serviceEndpoint"`
+}
+
+type DIDResolutionResult struct {
+	Context            string                `json:"@context"`
+	Document           json.RawMessage       `json:"didDocument"`
+	ResolutionMetadata DIDResolutionMetadata `json:"didResolutionMetadata"`
+	DocumentMetadata   DIDDocumentMetadata   `json:"didDocumentMetadata"`
+}
+
+type DIDResolutionMetadata struct {
+	ContentType string `json:"contentType,omitempty"`
+}
+
+type DIDDocumentMetadata struct {
+	Owner         crypto.Address `json:"owner"`
+	Created       int64          `json:"created"`
+	Updated       int64          `json:"updated,omitempty"`
+	Deactivated   bool           `json:"deactivated,omitempty"`
+	VersionID     string         `json:"versionId,omitempty"`
+	NextUpdate    int64          `json:"nextUpdate,omitempty"`
+	NextVersionID string         `json:"nextVersionId,omitempty"`
+}
+
+// CheckDID checks if id conforms to the DID syntax and uses the DID method
+// of this chain.
+func CheckDID(id string) error {
+	if !didRegexp.MatchString(id) {
+		return errors.New("malformed DID")
+	}
+	if strings.SplitN(id, ":", 3)[1] != DIDMethod {
+		return errors.New("unsupported DID method")
+	}
+	return nil
+}
+
+// DIDAddress returns the account address which stands for the DID when a tx
+// is sent as the DID.
+func DIDAddress(id string) crypto.Address {
+	return crypto.Address(tmhash.SumTruncated([]byte(id)))
+}
+
+// ParseDIDDocument parses raw as a DID document and checks if it is a valid
+// document for the DID given as id.
+func ParseDIDDocument(id string, raw []byte) (*DIDDocument, error) {
+	var doc DIDDocument
+	err := json.Unmarshal(raw, &doc)
+	if err != nil {
+		return nil, err
+	}
+	err = doc.Check(id)
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (d *DIDDocument) Check(id string) error {
+	if d.ID != id {
+		return errors.New("id does not match the target")
+	}
+	if err := checkDIDController(d.Controller); err != nil {
+		return err
+	}
+
+	methods := make(map[string]bool)
+	for i := range d.VerificationMethod {
+		vm := &d.VerificationMethod[i]
+		if err := vm.Check(); err != nil {
+			return err
+		}
+		vmID := d.resolveURL(vm.ID)
+		if methods[vmID] {
+			return errors.New("duplicate verification method id")
+		}
+		methods[vmID] = true
+	}
+
+	for _, rel := range [][]json.RawMessage{
+		d.Authentication,
+		d.AssertionMethod,
+		d.KeyAgreement,
+		d.CapabilityInvocation,
+		d.CapabilityDelegation,
+	} {
+		for _, raw := range rel {
+			// either a reference to a verification method or an embedded one
+			var ref string
+			if json.Unmarshal(raw, &ref) == nil {
+				ref = d.resolveURL(ref)
+				if strings.HasPrefix(ref, d.ID+"#") && !methods[ref] {
+					return errors.New("unknown verification method: " + ref)
+				}
+				if !strings.HasPrefix(ref, "did:") {
+					return errors.New("malformed verification method reference")
+				}
+				continue
+			}
+			var vm VerificationMethod
+			if err := json.Unmarshal(raw, &vm); err != nil {
+				return err
+			}
+			if err := vm.Check(); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, svc := range d.Service {
+		if len(svc.ID) == 0 {
+			return errors.New("empty service id")
+		}
+		if svc.Type == nil {
+			return errors.New("empty service type")
+		}
+		if len(svc.ServiceEndpoint) == 0 {
+			return errors.New("empty service endpoint")
+		}
+	}
+
+	return nil
+}
+
+// GetVerificationMethod returns the verification method identified by the
+// DID URL given as id, which may be relative to the document.
+func (d *DIDDocument) GetVerificationMethod(id string) *VerificationMethod {
+	id = d.resolveURL(id)
+	for i := range d.VerificationMethod {
+		if d.resolveURL(d.VerificationMethod[i].ID) == id {
+			return &d.VerificationMethod[i]
+		}
+	}
+	return nil
+}
+
+// GetAuthenticationMethod returns the verification method identified by the
+// DID URL given as id, only when it is authorized for authentication.
+func (d *DIDDocument) GetAuthenticationMethod(id string) *VerificationMethod {
+	id = d.resolveURL(id)
+	for _, raw := range d.Authentication {
+		var ref string
+		if json.Unmarshal(raw, &ref) == nil {
+			if d.resolveURL(ref) == id {
+				return d.GetVerificationMethod(id)
+			}
+			continue
+		}
+		var vm VerificationMethod
+		if json.Unmarshal(raw, &vm) == nil && d.resolveURL(vm.ID) == id {
+			return &vm
+		}
+	}
+	return nil
+}
+
+func (d *DIDDocument) resolveURL(url string) string {
+	if strings.HasPrefix(url, "#") {
+		return d.ID + url
+	}
+	return url
+}
+
+func checkDIDController(controller interface{}) error {
+	switch c := controller.(type) {
+	case nil:
+		return nil
+	case string:
+		if !didRegexp.MatchString(c) {
+			return errors.New("malformed controller")
+		}
+	case []interface{}:
+		for _, e := range c {
+			s, ok := e.(string)
+			if !ok || !didRegexp.MatchString(s) {
+				return errors.New("malformed controller")
+			}
+		}
+	default:
+		return errors.New("malformed controller")
+	}
+	return nil
+}
+
+func (vm *VerificationMethod) Check() error {
+	if len(vm.ID) == 0 {
+		return errors.New("empty verification method id")
+	}
+	if !strings.HasPrefix(vm.ID, "#") && !strings.HasPrefix(vm.ID, "did:") {
+		return errors.New("malformed verification method id")
+	}
+	if len(vm.Type) == 0 {
+		return errors.New("empty verification method type")
+	}
+	if !didRegexp.MatchString(vm.Controller) {
+		return errors.New("malformed verification method controller")
+	}
+	if vm.PublicKeyJwk == nil && len(vm.PublicKeyMultibase) == 0 {
+		return errors.New("no public key material")
+	}
+	if vm.PublicKeyJwk != nil && len(vm.PublicKeyMultibase) > 0 {
+		return errors.New("more than one public key material")
+	}
+	if vm.PublicKeyJwk != nil {
+		return vm.PublicKeyJwk.Check()
+	}
+	return nil
+}
+
+func (k *JWK) Check() error {
+	if len(k.Kty) == 0 {
+		return errors.New("empty kty")
+	}
+	if len(k.D) > 0 {
+		return errors.New("private key in public key material")
+	}
+	if k.Kty == "EC" && k.Crv == "P-256" {
+		_, _, err := k.P256Point()
+		return err
+	}
+	return nil
+}
+
+// P256Point returns the coordinates of a P-256 public key, making sure that
+// the point is on the curve.
+func (k *JWK) P256Point() (*big.Int, *big.Int, error) {
+	if k.Kty != "EC" || k.Crv != "P-256" {
+		return nil, nil, errors.New("not a P-256 key")
+	}
+	xb, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil || len(xb) != 32 {
+		return nil, nil, errors.New("malformed x coordinate")
+	}
+	yb, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil || len(yb) != 32 {
+		return nil, nil, errors.New("malformed y coordinate")
+	}
+	x := new(big.Int).SetBytes(xb)
+	y := new(big.Int).SetBytes(yb)
+	if !elliptic.P256().IsOnCurve(x, y) {
+		return nil, nil, errors.New("point not on curve")
+	}
+	return x, y, nil
+}
+
+// VersionTime returns the height at which the entry got its current version.
+func (e *DIDEntry) VersionTime() int64 {
+	if e.Updated > 0 {
+		return e.Updated
+	}
+	return e.Created
+}
+
+// ResolutionResult builds the result of resolving the DID of the entry.
+func (e *DIDEntry) ResolutionResult() *DIDResolutionResult {
+	res := &DIDResolutionResult{
+		Context:  DIDResolutionContext,
+		Document: e.Document,
+		DocumentMetadata: DIDDocumentMetadata{
+			Owner:       e.Owner,
+			Created:     e.Created,
+			Updated:     e.Updated,
+			Deactivated: e.Deactivated,
+		},
+	}
+	if e.Version > 0 {
+		res.DocumentMetadata.VersionID = strconv.FormatUint(e.Version, 10)
+	}
+	if !e.Deactivated && len(e.Document) > 0 {
+		var doc struct {
+			Context interface{} `json:"@context"`
+		}
+		json.Unmarshal(e.Document, &doc)
+		if doc.Context != nil {
+			res.ResolutionMetadata.ContentType = DIDContentTypeLD
+		} else {
+			res.ResolutionMetadata.ContentType = DIDContentTypeJSON
+		}
+	}
+	return res
 }
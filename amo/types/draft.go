@@ -19,6 +19,8 @@ type Draft struct {
 	TallyQuorum  Currency `json:"tally_quorum"`
 	TallyApprove Currency `json:"tally_approve"`
 	TallyReject  Currency `json:"tally_reject"`
+
+	StorageSlash *StorageSlash `json:"storage_slash,omitempty"`
 }
 
 // DraftForQuery structure is an alternative one to contain AMOAppConfig
@@ -45,6 +47,8 @@ type DraftForQuery struct {
 	TallyQuorum  Currency `json:"tally_quorum"`
 	TallyApprove Currency `json:"tally_approve"`
 	TallyReject  Currency `json:"tally_reject"`
+
+	StorageSlash *StorageSlash `json:"storage_slash,omitempty"`
 }
 
 type DraftEx struct {
@@ -0,0 +1,12 @@
+package types
+
+import (
+	"github.com/tendermint/tendermint/libs/bytes"
+)
+
+// EncKey is a public key published by an account, to which the custody of a
+// parcel granted to the account is encrypted.
+type EncKey struct {
+	Key    bytes.HexBytes `json:"key"`
+	Height int64          `json:"height"` // height at which the key is set
+}
@@ -0,0 +1,17 @@
+package types
+
+import (
+	"github.com/tendermint/tendermint/crypto"
+)
+
+// Escrow holds the payment for a parcel after grant until the recipient
+// confirms it or the hold period ends. A disputed escrow is held until the
+// arbiter of the parcel resolves it.
+type Escrow struct {
+	Payer    crypto.Address `json:"payer"`
+	Payee    crypto.Address `json:"payee"`
+	Arbiter  crypto.Address `json:"arbiter"`
+	Amount   Currency       `json:"amount"`
+	End      int64          `json:"end"`
+	Disputed bool           `json:"disputed"`
+}
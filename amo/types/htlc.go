@@ -0,0 +1,45 @@
+package types
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"github.com/tendermint/tendermint/crypto"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+)
+
+const HTLCHashLockSize = sha256.Size
+
+// HTLC holds coins in escrow until the recipient reveals the preimage of the
+// hash lock or the timeout height is reached. A closed HTLC is kept to show
+// the preimage revealed and not to be created again.
+type HTLC struct {
+	Sender    crypto.Address   `json:"sender"`
+	Recipient crypto.Address   `json:"recipient"`
+	UDC       uint32           `json:"udc,omitempty"`
+	Amount    Currency         `json:"amount"`
+	HashLock  tmbytes.HexBytes `json:"hash_lock"`
+	Timeout   int64            `json:"timeout"`
+	Preimage  tmbytes.HexBytes `json:"preimage,omitempty"` // set when claimed
+	Refunded  bool             `json:"refunded,omitempty"`
+}
+
+// HTLCID derives the id of an HTLC, so that the counterparty of a swap can
+// find it without looking into the tx creating it.
+func HTLCID(sender, recipient crypto.Address, hashLock []byte) tmbytes.HexBytes {
+	h := sha256.New()
+	h.Write(sender)
+	h.Write(recipient)
+	h.Write(hashLock)
+	return h.Sum(nil)
+}
+
+func (h *HTLC) Closed() bool {
+	return len(h.Preimage) > 0 || h.Refunded
+}
+
+// Unlocks checks if preimage matches the hash lock.
+func (h *HTLC) Unlocks(preimage []byte) bool {
+	sum := sha256.Sum256(preimage)
+	return bytes.Equal(sum[:], h.HashLock)
+}
@@ -0,0 +1,26 @@
+package types
+
+import (
+	"github.com/tendermint/tendermint/crypto"
+)
+
+// role bits of a parcel operator
+const (
+	RoleGrant uint8 = 1 << iota
+	RoleRevoke
+	RoleDiscard
+	RoleEdit // metadata edit by re-register
+
+	RoleAll = RoleGrant | RoleRevoke | RoleDiscard | RoleEdit
+)
+
+// Operator is an account delegated by the owner of a parcel to do some of the
+// owner's operations on the parcel.
+type Operator struct {
+	Roles uint8 `json:"roles"`
+}
+
+type OperatorEx struct {
+	*Operator
+	Address crypto.Address `json:"address"`
+}
@@ -0,0 +1,70 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/tendermint/tendermint/crypto"
+)
+
+const (
+	OrderBuy  = "buy"
+	OrderSell = "sell"
+)
+
+// Order is a limit order to trade the base coin of a market for the quote
+// coin. The price is the amount of the quote coin for one whole base coin,
+// i.e. OneAMOUint64 units of it. Zero as a coin means AMO.
+type Order struct {
+	Owner  crypto.Address `json:"owner"`
+	Base   uint32         `json:"base"`
+	Quote  uint32         `json:"quote"`
+	Side   string         `json:"side"`
+	Price  Currency       `json:"price"`
+	Amount Currency       `json:"amount"` // base coin yet to be filled
+	Escrow Currency       `json:"escrow"` // coin to sell held in escrow
+	Height int64          `json:"height"` // height at which the order is placed
+}
+
+type OrderEx struct {
+	ID uint64 `json:"id"`
+	*Order
+}
+
+type OrderList struct {
+	Orders []*OrderEx `json:"orders"`
+	Next   uint64     `json:"next,omitempty"`
+}
+
+type PriceLevel struct {
+	Price  Currency `json:"price"`
+	Amount Currency `json:"amount"`
+}
+
+type OrderBookDepth struct {
+	Base  uint32       `json:"base"`
+	Quote uint32       `json:"quote"`
+	Bids  []PriceLevel `json:"bids"`
+	Asks  []PriceLevel `json:"asks"`
+}
+
+// QuoteAmount returns the amount of the quote coin for the amount of the base
+// coin at the price, rounded up if roundUp is true and down otherwise.
+func QuoteAmount(amount, price *Currency, roundUp bool) *Currency {
+	unit := new(big.Int).SetUint64(OneAMOUint64)
+	quote := new(Currency)
+	quote.Int.Mul(&amount.Int, &price.Int)
+	if roundUp {
+		quote.Int.Add(&quote.Int, unit)
+		quote.Int.Sub(&quote.Int, big.NewInt(1))
+	}
+	quote.Int.Quo(&quote.Int, unit)
+	return quote
+}
+
+// SellCoin returns the coin held in escrow for the order.
+func (o *Order) SellCoin() uint32 {
+	if o.Side == OrderBuy {
+		return o.Quote
+	}
+	return o.Base
+}
@@ -1,7 +1,11 @@
 package types
 
 import (
+	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"mime"
+	"strings"
 
 	"github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/libs/bytes"
@@ -17,12 +21,52 @@ type Parcel struct {
 	Owner        crypto.Address `json:"owner"`
 	Custody      bytes.HexBytes `json:"custody"`
 	ProxyAccount crypto.Address `json:"proxy_account,omitempty"`
+	ContentRoot  bytes.HexBytes `json:"content_root,omitempty"`
+	ChunkCount   uint64         `json:"chunk_count,omitempty"`
+	Digest       bytes.HexBytes `json:"digest,omitempty"` // multihash
+	Size         uint64         `json:"size,omitempty"`
+	MimeType     string         `json:"mime_type,omitempty"`
+	Terms        *Terms         `json:"terms,omitempty"`
+	HoldPeriod   int64          `json:"hold_period,omitempty"`
+	Arbiter      crypto.Address `json:"arbiter,omitempty"`
 	Extra        Extra          `json:"extra,omitempty"`
 	OnSale       bool           `json:"on_sale"`
 }
 
 type ParcelEx struct {
 	*Parcel
-	Requests []*RequestEx `json:"requests,omitempty"`
-	Usages   []*UsageEx   `json:"usages,omitempty"`
+	Requests  []*RequestEx  `json:"requests,omitempty"`
+	Usages    []*UsageEx    `json:"usages,omitempty"`
+	Operators []*OperatorEx `json:"operators,omitempty"`
+}
+
+// CheckMultihash checks if *mh* is a well-formed multihash, which is composed
+// of a varint hash function code, a varint digest length and the digest.
+func CheckMultihash(mh []byte) error {
+	_, n := binary.Uvarint(mh)
+	if n <= 0 {
+		return errors.New("improper multihash code")
+	}
+	mh = mh[n:]
+	l, n := binary.Uvarint(mh)
+	if n <= 0 {
+		return errors.New("improper multihash length")
+	}
+	if l == 0 || uint64(len(mh[n:])) != l {
+		return errors.New("multihash length mismatch")
+	}
+	return nil
+}
+
+// CheckMimeType checks if *mimeType* is a valid media type as in RFC 2045.
+func CheckMimeType(mimeType string) error {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return err
+	}
+	i := strings.Index(mediaType, "/")
+	if i <= 0 || i == len(mediaType)-1 {
+		return errors.New("improper mime type")
+	}
+	return nil
 }
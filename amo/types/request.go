@@ -2,6 +2,7 @@ package types
 
 import (
 	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/libs/bytes"
 )
 
 type Request struct {
@@ -9,10 +10,19 @@ type Request struct {
 	Agency    crypto.Address `json:"agency,omitempty"`
 	Dealer    crypto.Address `json:"dealer,omitempty"`
 	DealerFee Currency       `json:"dealer_fee,omitempty"`
-	Extra     Extra          `json:"extra,omitempty"`
+	Terms     *Terms         `json:"terms,omitempty"`
+	// snapshot of the encryption key of the recipient at the time of request
+	RecipientKey bytes.HexBytes `json:"recipient_key,omitempty"`
+	Extra        Extra          `json:"extra,omitempty"`
 }
 
 type RequestEx struct {
 	*Request
 	Recipient crypto.Address `json:"recipient"`
+	Target    bytes.HexBytes `json:"target,omitempty"`
+}
+
+type RequestList struct {
+	Requests []*RequestEx   `json:"requests"`
+	Next     bytes.HexBytes `json:"next,omitempty"`
 }
@@ -0,0 +1,25 @@
+package types
+
+import (
+	"github.com/tendermint/tendermint/crypto"
+)
+
+// Schedule is a transfer to be made at the start height, and then repeatedly
+// every interval until it is made count times. When funded by escrow, the
+// coins for all the transfers are held in escrow from the beginning.
+// Otherwise, the sender is charged at each transfer.
+type Schedule struct {
+	Sender    crypto.Address `json:"sender"`
+	Recipient crypto.Address `json:"recipient"`
+	UDC       uint32         `json:"udc,omitempty"`
+	Amount    Currency       `json:"amount"`
+	Interval  int64          `json:"interval,omitempty"`
+	Count     uint64         `json:"count"` // transfers yet to be made
+	Next      int64          `json:"next"`  // height of the next transfer
+	Escrow    *Currency      `json:"escrow,omitempty"`
+}
+
+type ScheduleEx struct {
+	ID uint64 `json:"id"`
+	*Schedule
+}
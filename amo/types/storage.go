@@ -1,6 +1,8 @@
 package types
 
 import (
+	"encoding/json"
+
 	"github.com/tendermint/tendermint/crypto"
 )
 
@@ -11,5 +13,36 @@ type Storage struct {
 	Url             string         `json:"url"`
 	RegistrationFee Currency       `json:"registration_fee"`
 	HostingFee      Currency       `json:"hosting_fee"`
+	Bond            Currency       `json:"bond,omitempty"`
 	Active          bool           `json:"active"`
 }
+
+// MarshalJSON leaves out the bond when there is none, so that a storage set up
+// without a bond is encoded as it was before protocol v6.
+func (s Storage) MarshalJSON() ([]byte, error) {
+	var bond *Currency
+	if !s.Bond.Equals(Zero) {
+		bond = &s.Bond
+	}
+	return json.Marshal(struct {
+		Owner           crypto.Address `json:"owner"`
+		Url             string         `json:"url"`
+		RegistrationFee Currency       `json:"registration_fee"`
+		HostingFee      Currency       `json:"hosting_fee"`
+		Bond            *Currency      `json:"bond,omitempty"`
+		Active          bool           `json:"active"`
+	}{s.Owner, s.Url, s.RegistrationFee, s.HostingFee, bond, s.Active})
+}
+
+// StorageBond is a bond which is still locked after its storage got closed.
+type StorageBond struct {
+	Owner  crypto.Address `json:"owner"`
+	Amount Currency       `json:"amount"`
+}
+
+// StorageSlash describes a portion of storage bond to be slashed and
+// distributed to the usage holders of the parcels in the storage.
+type StorageSlash struct {
+	Storage uint32  `json:"storage"`
+	Ratio   float64 `json:"ratio"`
+}
@@ -0,0 +1,66 @@
+package types
+
+import (
+	"errors"
+)
+
+// Terms describes license terms of a parcel. The owner of a parcel declares
+// terms when registering it, and a requester accepts a subset of them.
+type Terms struct {
+	Purposes      []string `json:"purposes,omitempty"`
+	Redistribute  bool     `json:"redistribute"`
+	Expiry        int64    `json:"expiry,omitempty"` // block height, 0 for no expiry
+	Jurisdictions []string `json:"jurisdictions,omitempty"`
+}
+
+func (t *Terms) Check() error {
+	for _, p := range t.Purposes {
+		if len(p) == 0 {
+			return errors.New("empty purpose")
+		}
+	}
+	if t.Expiry < 0 {
+		return errors.New("negative expiry")
+	}
+	for _, j := range t.Jurisdictions {
+		if len(j) == 0 {
+			return errors.New("empty jurisdiction")
+		}
+	}
+	return nil
+}
+
+// Accepts checks if *sub* is a subset of the terms, i.e. *sub* is no more
+// permissive than the terms.
+func (t *Terms) Accepts(sub *Terms) error {
+	// no purpose stated does not mean any purpose
+	if len(t.Purposes) > 0 && len(sub.Purposes) == 0 {
+		return errors.New("no purpose stated")
+	}
+	if !isSubset(sub.Purposes, t.Purposes) {
+		return errors.New("purposes not allowed")
+	}
+	if sub.Redistribute && !t.Redistribute {
+		return errors.New("redistribution not allowed")
+	}
+	if t.Expiry != 0 && (sub.Expiry == 0 || sub.Expiry > t.Expiry) {
+		return errors.New("expiry beyond the terms")
+	}
+	if !isSubset(sub.Jurisdictions, t.Jurisdictions) {
+		return errors.New("jurisdictions not allowed")
+	}
+	return nil
+}
+
+func isSubset(sub, set []string) bool {
+	m := make(map[string]bool, len(set))
+	for _, s := range set {
+		m[s] = true
+	}
+	for _, s := range sub {
+		if !m[s] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,12 +1,158 @@
 package types
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+
 	"github.com/tendermint/tendermint/crypto"
 )
 
 type UDC struct {
 	Owner     crypto.Address   `json:"owner"`     // required
 	Desc      string           `json:"desc"`      // optional
-	Operators []crypto.Address `json:"operators"` // optional
+	Operators []crypto.Address `json:"operators"` // optional, admins
 	Total     Currency         `json:"total"`     // required
+	// optional, zero for unlimited supply
+	MaxSupply Currency         `json:"max_supply,omitempty"`
+	Minters   []crypto.Address `json:"minters,omitempty"`
+	Lockers   []crypto.Address `json:"lockers,omitempty"`
+	Frozen    bool             `json:"frozen,omitempty"`
+}
+
+// MarshalJSON leaves out the max supply when it is unlimited, so that a UDC
+// issued without a supply cap is encoded as it was before protocol v6.
+func (u UDC) MarshalJSON() ([]byte, error) {
+	var maxSupply *Currency
+	if !u.MaxSupply.Equals(Zero) {
+		maxSupply = &u.MaxSupply
+	}
+	return json.Marshal(struct {
+		Owner     crypto.Address   `json:"owner"`
+		Desc      string           `json:"desc"`
+		Operators []crypto.Address `json:"operators"`
+		Total     Currency         `json:"total"`
+		MaxSupply *Currency        `json:"max_supply,omitempty"`
+		Minters   []crypto.Address `json:"minters,omitempty"`
+		Lockers   []crypto.Address `json:"lockers,omitempty"`
+		Frozen    bool             `json:"frozen,omitempty"`
+	}{u.Owner, u.Desc, u.Operators, u.Total, maxSupply, u.Minters,
+		u.Lockers, u.Frozen})
+}
+
+// IsAdmin checks if addr can change the description and the roles other than
+// admins, and freeze the UDC. The owner has all the roles.
+func (u *UDC) IsAdmin(addr crypto.Address) bool {
+	return bytes.Equal(u.Owner, addr) || containsAddress(u.Operators, addr)
+}
+
+// IsMinter checks if addr can issue more of the UDC.
+func (u *UDC) IsMinter(addr crypto.Address) bool {
+	return bytes.Equal(u.Owner, addr) || containsAddress(u.Minters, addr)
+}
+
+// IsLocker checks if addr can lock the UDC in the balance of a holder.
+func (u *UDC) IsLocker(addr crypto.Address) bool {
+	return bytes.Equal(u.Owner, addr) || containsAddress(u.Lockers, addr)
+}
+
+func containsAddress(list []crypto.Address, addr crypto.Address) bool {
+	for _, a := range list {
+		if bytes.Equal(a, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// UDCLock is an amount of UDC locked in the balance of a holder. When a
+// schedule is given, the amount is locked until the cliff height, and then
+// gets released linearly until the end height.
+type UDCLock struct {
+	Amount Currency `json:"amount"`
+	Start  int64    `json:"start,omitempty"` // height at which the lock is set
+	Cliff  int64    `json:"cliff,omitempty"` // 0 for no cliff
+	End    int64    `json:"end,omitempty"`   // 0 for no vesting
+}
+
+type UDCLockEx struct {
+	*UDCLock
+	Locked Currency `json:"locked"` // amount still locked at the height
+}
+
+// UnmarshalJSON accepts a plain amount as well, which is the format of a lock
+// without any schedule in the older versions.
+func (l *UDCLock) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*l = UDCLock{}
+		return l.Amount.UnmarshalJSON(data)
+	}
+	type udcLock UDCLock
+	return json.Unmarshal(data, (*udcLock)(l))
+}
+
+// UnmarshalJSON is defined here not to let the method of the embedded UDCLock
+// take over, which would leave Locked untouched.
+func (l *UDCLockEx) UnmarshalJSON(data []byte) error {
+	var ex struct {
+		Locked Currency `json:"locked"`
+	}
+	err := json.Unmarshal(data, &ex)
+	if err != nil {
+		return err
+	}
+	l.UDCLock = new(UDCLock)
+	l.Locked = ex.Locked
+	return l.UDCLock.UnmarshalJSON(data)
+}
+
+func (l *UDCLock) Check() error {
+	if l.Cliff < 0 || l.End < 0 {
+		return errors.New("negative height")
+	}
+	if l.End > 0 && l.Cliff > l.End {
+		return errors.New("cliff after end")
+	}
+	if l.End > 0 && l.End <= l.Start {
+		return errors.New("end before start")
+	}
+	return nil
+}
+
+// Locked returns the amount still locked at the height.
+func (l *UDCLock) Locked(height int64) *Currency {
+	locked := new(Currency)
+	switch {
+	case l.End == 0 && (l.Cliff == 0 || height < l.Cliff):
+		locked.Int.Set(&l.Amount.Int)
+	case l.End == 0:
+		// released at once
+	case height < l.Cliff || height < l.Start:
+		locked.Int.Set(&l.Amount.Int)
+	case height >= l.End:
+		// fully released
+	default:
+		locked.Int.Mul(&l.Amount.Int, big.NewInt(l.End-height))
+		locked.Int.Quo(&locked.Int, big.NewInt(l.End-l.Start))
+	}
+	return locked
+}
+
+type UDCHolder struct {
+	Address crypto.Address `json:"address"`
+	Balance Currency       `json:"balance"`
+	Lock    *UDCLockEx     `json:"lock,omitempty"`
+}
+
+type UDCHolderList struct {
+	Holders []*UDCHolder   `json:"holders"`
+	Next    crypto.Address `json:"next,omitempty"`
+}
+
+type UDCStats struct {
+	Holders     uint64   `json:"holders"`
+	Total       Currency `json:"total"`
+	Locked      Currency `json:"locked"`
+	Circulating Currency `json:"circulating"` // total excluding locked
 }
@@ -6,11 +6,23 @@ import (
 )
 
 type Usage struct {
-	Custody bytes.HexBytes `json:"custody"`
-	Extra   Extra          `json:"extra,omitempty"`
+	Custody     bytes.HexBytes `json:"custody"`
+	Agency      crypto.Address `json:"agency,omitempty"`
+	Extra       Extra          `json:"extra,omitempty"`
+	AccessCount uint64         `json:"access_count,omitempty"`
+	LastAccess  int64          `json:"last_access,omitempty"`
+	MaxAccess   uint64         `json:"max_access,omitempty"` // 0 for unlimited
+	Terms       *Terms         `json:"terms,omitempty"`
 }
 
 type UsageEx struct {
 	*Usage
 	Recipient crypto.Address `json:"recipient"`
+	Target    bytes.HexBytes `json:"target,omitempty"`
+	Escrow    *Escrow        `json:"escrow,omitempty"`
+}
+
+type UsageList struct {
+	Usages []*UsageEx     `json:"usages"`
+	Next   bytes.HexBytes `json:"next,omitempty"`
 }
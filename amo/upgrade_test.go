@@ -135,23 +135,51 @@ func TestProtocolDifference(t *testing.T) {
 	app.EndBlock(abci.RequestEndBlock{Height: 10})
 	app.Commit()
 
-	app.config.UpgradeProtocolHeight = 11
+	// challenge tx before protocol 6: should be rejected
+	tx3 := []byte(`{"type":"challenge","sender":"85FE85FCE6AB426563E5E0749EBCB95E9B1EF1D5","payload":{"target":"00000010EFEF"},"signature":{"pubkey":"0485FE85FCE6AB426563E5E085FE85FCE6AB426563E5E0749EBCB95E9B185FE85FCE6AB426563E5E085FE85FCE6AB426563E5E0749EBCB95E9B1EF1D55E9B1EF1D","sig_bytes":"BFFFFFFF"}}`)
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 11}})
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: tx3})
+	assert.Equal(t, code.TxCodeUnknown, res.Code)
+	app.EndBlock(abci.RequestEndBlock{Height: 11})
+	app.Commit()
+
+	app.config.UpgradeProtocolHeight = 12
 	app.config.UpgradeProtocolVersion = 0x6
+	b, err = json.Marshal(app.config)
+	assert.NoError(t, err)
+	err = app.store.SetAppConfig(b)
+	assert.NoError(t, err)
+
+	// protocol 5 -> 6
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 12}})
+	assert.Equal(t, uint64(0x6), app.state.ProtocolVersion)
+	assert.NotNil(t, app.proto)
+	assert.Equal(t, uint64(0x6), app.proto.Version())
+	// challenge tx again: should be accepted
+	// (but rejected since the parcel is not found)
+	tx4 := []byte(`{"type":"challenge","sender":"85FE85FCE6AB426563E5E0749EBCB95E9B1EF1D5","payload":{"target":"00000010EFEF"},"signature":{"pubkey":"0485FE85FCE6AB426563E5E085FE85FCE6AB426563E5E0749EBCB95E9B185FE85FCE6AB426563E5E085FE85FCE6AB426563E5E0749EBCB95E9B1EF1D55E9B1EF1D","sig_bytes":"CFFFFFFF"}}`)
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: tx4})
+	assert.Equal(t, code.TxCodeParcelNotFound, res.Code)
+	app.EndBlock(abci.RequestEndBlock{Height: 12})
+	app.Commit()
+
+	app.config.UpgradeProtocolHeight = 13
+	app.config.UpgradeProtocolVersion = 0x7
 
 	// The following will panic, so we will use a different testing point.
 	//b, err = json.Marshal(app.config)
 	//assert.NoError(t, err)
 	//err = app.store.SetAppConfig(b)
 	//assert.NoError(t, err)
-	//app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 11}})
-	//app.EndBlock(abci.RequestEndBlock{Height: 11})
+	//app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 13}})
+	//app.EndBlock(abci.RequestEndBlock{Height: 13})
 	//app.Commit()
-	app.state.Height = 11
+	app.state.Height = 13
 	app.upgradeProtocol()
 
-	assert.Equal(t, uint64(0x6), app.state.ProtocolVersion)
+	assert.Equal(t, uint64(0x7), app.state.ProtocolVersion)
 	assert.Nil(t, app.proto)
 	err = checkProtocolVersion(app.state.ProtocolVersion)
-	assert.Error(t, err) // protocol version 6 is not supported
+	assert.Error(t, err) // protocol version 7 is not supported
 }
 